@@ -45,6 +45,28 @@ const (
 	ApiCodeInvalidArgument = 18
 	// 敏感文件，禁止上传
 	ApiCodeInfoSecurityError = 19
+	// 云盘剩余空间不足
+	ApiCodeInsufficientCapacity = 20
+	// 文件大小超过上传限制
+	ApiCodeFileTooLarge = 21
+	// 文件名无效，包含特殊字符或者过长
+	ApiCodeInvalidFileName = 22
+	// 文件路径过长
+	ApiCodeFilePathTooLong = 23
+	// 分享已过期
+	ApiCodeShareExpired = 24
+	// 分享审核中或者审核未通过
+	ApiCodeShareAudited = 25
+	// 分享需要访问码或者访问码错误
+	ApiCodeShareNeedAccessCode = 26
+	// 分享不存在或者已取消
+	ApiCodeShareNotFound = 27
+	// 登录二维码已失效
+	ApiCodeQrCodeExpired = 28
+	// 登录需要短信验证码
+	ApiCodeNeedSmsCode = 29
+	// 登录需要设备验证
+	ApiCodeNeedDeviceVerify = 30
 )
 
 type ApiCode int
@@ -23,7 +23,9 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"math/rand"
 	"net/http"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -45,6 +47,18 @@ FlhDeqVOG094hFJvZeK4OzA6HVwzwnEW5vIZ7d+u61RV1bsFxmB68+8JXs3ycGcE
 var (
 	// UUID for client sn
 	clientSn = strings.ToUpper(uuid.NewV4().String())
+
+	// 文件名特殊字符和对应的全角替代字符，原文件名中本身包含的全角字符使用"％"转义，保证可以还原
+	fileNameSanitizer = strings.NewReplacer(
+		"\\", "＼", "/", "／", ":", "：", "*", "＊", "?", "？",
+		"\"", "＂", "<", "＜", ">", "＞", "|", "｜",
+		"％", "％％", "＼", "％＼", "／", "％／", "：", "％：", "＊", "％＊", "？", "％？",
+		"＂", "％＂", "＜", "％＜", "＞", "％＞", "｜", "％｜")
+	fileNameRestorer = strings.NewReplacer(
+		"％％", "％", "％＼", "＼", "％／", "／", "％：", "：", "％＊", "＊", "％？", "？",
+		"％＂", "＂", "％＜", "＜", "％＞", "＞", "％｜", "｜",
+		"＼", "\\", "／", "/", "：", ":", "＊", "*", "？", "?",
+		"＂", "\"", "＜", "<", "＞", ">", "｜", "|")
 )
 
 func init() {
@@ -178,3 +192,37 @@ func CheckFileNameValid(name string) bool {
 	}
 	return !strings.ContainsAny(name, FileNameSpecialChars)
 }
+
+// SanitizeFileName 将文件名中的特殊字符替换为对应的全角字符，使其可以通过 CheckFileNameValid 检测
+// 替换后的文件名可以通过 RestoreFileName 还原，原文件名中本身包含的全角字符和"％"会被转义
+func SanitizeFileName(name string) string {
+	return fileNameSanitizer.Replace(name)
+}
+
+// RestoreFileName 还原由 SanitizeFileName 替换过的文件名
+func RestoreFileName(name string) string {
+	return fileNameRestorer.Replace(name)
+}
+
+// AutoRenameFileName 生成不冲突的文件名，格式为 "name (1).ext"，exists 用于检测文件名是否已存在
+// 文件夹不区分扩展名，格式为 "name (1)"
+func AutoRenameFileName(name string, isFolder bool, exists func(name string) bool) string {
+	if !exists(name) {
+		return name
+	}
+	base, ext := name, ""
+	if !isFolder {
+		ext = path.Ext(name)
+		if ext == name {
+			// 以.开头的隐藏文件，例如 .bashrc
+			ext = ""
+		}
+		base = strings.TrimSuffix(name, ext)
+	}
+	for i := 1; ; i++ {
+		newName := base + " (" + strconv.Itoa(i) + ")" + ext
+		if !exists(newName) {
+			return newName
+		}
+	}
+}
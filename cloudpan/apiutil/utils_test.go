@@ -73,4 +73,28 @@ func TestRand(t *testing.T) {
 func TestDateOfGmtStr(t *testing.T) {
 	r := DateOfGmtStr()
 	fmt.Println(r)
-}
\ No newline at end of file
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	name := "a\\b/c:d*e?f\"g<h>i|j.txt"
+	assert.False(t, CheckFileNameValid(name))
+	sanitized := SanitizeFileName(name)
+	assert.True(t, CheckFileNameValid(sanitized))
+	assert.Equal(t, name, RestoreFileName(sanitized))
+	assert.Equal(t, "normal.txt", SanitizeFileName("normal.txt"))
+
+	// 本身包含全角字符和转义字符的文件名也可以还原
+	for _, n := range []string{"a：b?.txt", "100％：.txt", "％％＼\\"} {
+		assert.Equal(t, n, RestoreFileName(SanitizeFileName(n)))
+		assert.True(t, CheckFileNameValid(SanitizeFileName(n)))
+	}
+}
+
+func TestAutoRenameFileName(t *testing.T) {
+	existed := map[string]bool{"a.txt": true, "a (1).txt": true, "dir.v1": true, ".bashrc": true}
+	exists := func(name string) bool { return existed[name] }
+	assert.Equal(t, "b.txt", AutoRenameFileName("b.txt", false, exists))
+	assert.Equal(t, "a (2).txt", AutoRenameFileName("a.txt", false, exists))
+	assert.Equal(t, "dir.v1 (1)", AutoRenameFileName("dir.v1", true, exists))
+	assert.Equal(t, ".bashrc (1)", AutoRenameFileName(".bashrc", false, exists))
+}
@@ -58,15 +58,9 @@ func (p *PanClient) AppCreateBatchTask(familyId int64, param *BatchTaskParam) (t
 	}
 
 	logger.Verboseln("do request url: " + fullUrl.String())
-	taskInfosStr, err := json.Marshal(param.TaskInfos)
-	var postData map[string]string
-	if BatchTaskTypeDelete == param.TypeFlag {
-		postData = map[string]string {
-			"type": string(param.TypeFlag),
-			"taskInfos": string(taskInfosStr),
-		}
-	} else {
-		return "", apierror.NewFailedApiError("不支持的操作")
+	postData, apiErr := batchTaskPostData(param)
+	if apiErr != nil {
+		return "", apiErr
 	}
 
 	// add common parameters
@@ -139,4 +133,100 @@ func (p *PanClient) AppCheckBatchTask (typeFlag BatchTaskType, taskId string) (r
 		return nil, apierror.NewApiErrorWithError(err)
 	}
 	return item, nil
-}
\ No newline at end of file
+}
+
+// AppGetConflictTaskInfo 获取家庭云批量任务中存在同名冲突的文件列表
+func (p *PanClient) AppGetConflictTaskInfo(familyId int64, typeFlag BatchTaskType, taskId string) (result *BatchTaskConflictInfo, error *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/batch/getConflictTaskInfo.action", API_URL)
+	sessionKey := p.appToken.FamilySessionKey
+	sessionSecret := p.appToken.FamilySessionSecret
+	httpMethod := "POST"
+	dateOfGmt := apiutil.DateOfGmtStr()
+	headers := map[string]string {
+		"Date": dateOfGmt,
+		"SessionKey": sessionKey,
+		"Signature": apiutil.SignatureOfHmac(sessionSecret, sessionKey, httpMethod, fullUrl.String(), dateOfGmt),
+		"X-Request-ID": apiutil.XRequestId(),
+		"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8",
+	}
+
+	logger.Verboseln("do request url: " + fullUrl.String())
+	postData := map[string]string {
+		"type": string(typeFlag),
+		"taskId": taskId,
+		"familyId": strconv.FormatInt(familyId, 10),
+		"clientType": "TELEPC",
+		"version": "6.2",
+		"channelId": "web_cloud.189.cn",
+		"rand": apiutil.Rand(),
+	}
+	respBody, err := p.client.Fetch(httpMethod, fullUrl.String(), postData, headers)
+	if err != nil {
+		logger.Verboseln("AppGetConflictTaskInfo failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	logger.Verboseln("response: " + string(respBody))
+
+	er := &apierror.AppErrorXmlResp{}
+	if err := xml.Unmarshal(respBody, er); err == nil {
+		if er.Code != "" {
+			return nil, apierror.NewFailedApiError("请求出错")
+		}
+	}
+
+	item := &BatchTaskConflictInfo{}
+	if err := xml.Unmarshal(respBody, item); err != nil {
+		logger.Verboseln("AppGetConflictTaskInfo response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	return item, nil
+}
+
+// AppManageBatchTask 提交家庭云批量任务的冲突处理方式，taskInfos 中每个文件需要指定 DealWay
+func (p *PanClient) AppManageBatchTask(familyId int64, typeFlag BatchTaskType, taskId, targetFolderId string, taskInfos BatchTaskInfoList) *apierror.ApiError {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/batch/manageBatchTask.action", API_URL)
+	sessionKey := p.appToken.FamilySessionKey
+	sessionSecret := p.appToken.FamilySessionSecret
+	httpMethod := "POST"
+	dateOfGmt := apiutil.DateOfGmtStr()
+	headers := map[string]string {
+		"Date": dateOfGmt,
+		"SessionKey": sessionKey,
+		"Signature": apiutil.SignatureOfHmac(sessionSecret, sessionKey, httpMethod, fullUrl.String(), dateOfGmt),
+		"X-Request-ID": apiutil.XRequestId(),
+		"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8",
+	}
+
+	logger.Verboseln("do request url: " + fullUrl.String())
+	taskInfosStr, _ := json.Marshal(taskInfos)
+	postData := map[string]string {
+		"type": string(typeFlag),
+		"taskId": taskId,
+		"targetFolderId": targetFolderId,
+		"taskInfos": string(taskInfosStr),
+		"familyId": strconv.FormatInt(familyId, 10),
+		"clientType": "TELEPC",
+		"version": "6.2",
+		"channelId": "web_cloud.189.cn",
+		"rand": apiutil.Rand(),
+	}
+	respBody, err := p.client.Fetch(httpMethod, fullUrl.String(), postData, headers)
+	if err != nil {
+		logger.Verboseln("AppManageBatchTask failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	logger.Verboseln("response: " + string(respBody))
+
+	er := &apierror.AppErrorXmlResp{}
+	if err := xml.Unmarshal(respBody, er); err == nil {
+		if er.Code != "" {
+			if er.Code == "InternalError" {
+				return apierror.NewFailedApiError("内部错误")
+			}
+			return apierror.NewFailedApiError("请求出错")
+		}
+	}
+	return nil
+}
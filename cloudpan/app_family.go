@@ -24,11 +24,14 @@ import (
 )
 
 type (
-	// AppGetFileInfoParam 获取文件信息参数
+	// FamilyUserRole 家庭云成员角色
+	FamilyUserRole int
+
+	// AppFamilyInfo 家庭云信息
 	AppFamilyInfo struct {
-		Count int `xml:"count" xml:"count"`
+		Count int `xml:"count" json:"count"`
 		Type int `xml:"type" json:"type"`
-		UserRole int `xml:"userRole" json:"userRole"`
+		UserRole FamilyUserRole `xml:"userRole" json:"userRole"`
 		CreateTime string `xml:"createTime" json:"createTime"`
 		FamilyId int64 `xml:"familyId" json:"familyId"`
 		RemarkName string `xml:"remarkName" json:"remarkName"`
@@ -42,6 +45,29 @@ type (
 
 )
 
+const (
+	// FamilyUserRoleMember 普通成员
+	FamilyUserRoleMember FamilyUserRole = 0
+	// FamilyUserRoleOwner 家庭创建者/管理员
+	FamilyUserRoleOwner FamilyUserRole = 1
+)
+
+// IsOwner 是否是家庭创建者
+func (r FamilyUserRole) IsOwner() bool {
+	return r == FamilyUserRoleOwner
+}
+
+func (r FamilyUserRole) String() string {
+	switch r {
+	case FamilyUserRoleOwner:
+		return "创建者"
+	case FamilyUserRoleMember:
+		return "成员"
+	default:
+		return "未知"
+	}
+}
+
 // AppGetFamilyList 获取用户的家庭列表
 func (p *PanClient) AppFamilyGetFamilyList() (*AppFamilyInfoListResult, *apierror.ApiError) {
 	fullUrl := &strings.Builder{}
@@ -0,0 +1,34 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+)
+
+// AppFamilyCopyFile 复制家庭云文件/文件夹到家庭云的目标文件夹，返回批量任务ID
+func (p *PanClient) AppFamilyCopyFile(familyId int64, taskInfos BatchTaskInfoList, targetFolderId string) (taskId string, error *apierror.ApiError) {
+	if len(taskInfos) == 0 {
+		return "", apierror.NewFailedApiError("请指定复制的文件")
+	}
+	if targetFolderId == "-11" {
+		targetFolderId = ""
+	}
+	return p.AppCreateBatchTask(familyId, &BatchTaskParam{
+		TypeFlag:       BatchTaskTypeCopy,
+		TaskInfos:      taskInfos,
+		TargetFolderId: targetFolderId,
+	})
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+)
+
+// AppFamilyDeleteFile 删除家庭云文件/文件夹，删除的文件会进入家庭云回收站，返回批量任务ID
+func (p *PanClient) AppFamilyDeleteFile(familyId int64, taskInfos BatchTaskInfoList) (taskId string, error *apierror.ApiError) {
+	if len(taskInfos) == 0 {
+		return "", apierror.NewFailedApiError("请指定删除的文件")
+	}
+	return p.AppCreateBatchTask(familyId, &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeDelete,
+		TaskInfos: taskInfos,
+	})
+}
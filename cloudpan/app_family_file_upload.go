@@ -27,6 +27,16 @@ import (
 )
 
 func (p *PanClient) AppFamilyCreateUploadFile(param *AppCreateUploadFileParam) (*AppCreateUploadFileResult, *apierror.ApiError) {
+	if err := validateCreateUpload(param); err != nil {
+		return nil, err
+	}
+	conflict, err := p.resolveUploadConflict(param)
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil && conflict.Skip {
+		return &AppCreateUploadFileResult{Conflict: conflict}, nil
+	}
 	if param.ParentFolderId == "-11" {
 		param.ParentFolderId = ""
 	}
@@ -66,6 +76,7 @@ func (p *PanClient) AppFamilyCreateUploadFile(param *AppCreateUploadFileParam) (
 		return nil, apierror.NewApiErrorWithError(err)
 	}
 	item.XRequestId = requestId
+	item.Conflict = conflict
 	return item, nil
 }
 
@@ -152,6 +163,31 @@ func (p *PanClient) AppFamilyUploadFileCommit(familyId int64, uploadCommitUrl, u
 	return item, nil
 }
 
+// AppFamilyUploadFileCommitOverwrite 提交家庭云上传文件并覆盖同名文件 existing。家庭云提交接口不支持覆盖，
+// 提交成功后再删除同名文件，并将自动重命名的新文件改回原文件名。existing 为nil时和 AppFamilyUploadFileCommit 相同
+func (p *PanClient) AppFamilyUploadFileCommitOverwrite(familyId int64, uploadCommitUrl, uploadFileId, xRequestId string, existing *AppFileEntity) (*AppUploadFileCommitResult, *apierror.ApiError) {
+	item, err := p.AppFamilyUploadFileCommit(familyId, uploadCommitUrl, uploadFileId, xRequestId)
+	if err != nil || existing == nil || item.Id == existing.FileId {
+		return item, err
+	}
+	drive := p.Family(familyId)
+	if err := drive.Delete(AppFileList{existing}); err != nil {
+		return item, err
+	}
+	if item.Name != existing.FileName {
+		uploaded := &AppFileEntity{
+			FileId:   item.Id,
+			ParentId: existing.ParentId,
+			FileName: item.Name,
+		}
+		if _, err := drive.Rename(uploaded, existing.FileName); err != nil {
+			return item, err
+		}
+		item.Name = existing.FileName
+	}
+	return item, nil
+}
+
 // AppFamilyGetUploadFileStatus 查询上传的文件状态
 func (p *PanClient) AppFamilyGetUploadFileStatus(familyId int64, uploadFileId string) (*AppGetUploadFileStatusResult, *apierror.ApiError) {
 	fullUrl := &strings.Builder{}
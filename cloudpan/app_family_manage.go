@@ -0,0 +1,174 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"strings"
+)
+
+type (
+	// AppFamilyMemberInfo 家庭云成员信息
+	AppFamilyMemberInfo struct {
+		// UserId 成员用户ID
+		UserId int64 `xml:"userId" json:"userId"`
+		// Account 成员账号，一般为手机号（模糊处理过的）
+		Account string `xml:"account" json:"account"`
+		// NickName 成员昵称
+		NickName string `xml:"nickname" json:"nickname"`
+		// RemarkName 成员备注名
+		RemarkName string `xml:"remarkName" json:"remarkName"`
+		// UserRole 成员角色
+		UserRole FamilyUserRole `xml:"userRole" json:"userRole"`
+		// JoinTime 加入时间
+		JoinTime string `xml:"joinTime" json:"joinTime"`
+	}
+
+	// AppFamilyMemberListResult 家庭云成员列表
+	AppFamilyMemberListResult struct {
+		XMLName    xml.Name               `xml:"familyMemberList"`
+		MemberList []*AppFamilyMemberInfo `xml:"familyMember" json:"memberList"`
+	}
+
+	// AppFamilyCapacityInfo 家庭云容量信息
+	AppFamilyCapacityInfo struct {
+		XMLName xml.Name `xml:"familyCapacity"`
+		// FamilyId 家庭云ID
+		FamilyId int64 `xml:"familyId" json:"familyId"`
+		// Capacity 家庭云总空间大小
+		Capacity int64 `xml:"capacity" json:"capacity"`
+		// UsedSize 家庭云已使用空间大小
+		UsedSize int64 `xml:"usedSize" json:"usedSize"`
+	}
+)
+
+// Available 家庭云剩余可用空间大小
+func (c *AppFamilyCapacityInfo) Available() int64 {
+	if c.Capacity <= c.UsedSize {
+		return 0
+	}
+	return c.Capacity - c.UsedSize
+}
+
+// doFamilyManageRequest 请求家庭云管理接口
+func (p *PanClient) doFamilyManageRequest(actName, fullUrl string) ([]byte, *apierror.ApiError) {
+	httpMethod := "GET"
+	dateOfGmt := apiutil.DateOfGmtStr()
+	sessionKey := p.appToken.FamilySessionKey
+	sessionSecret := p.appToken.FamilySessionSecret
+	headers := map[string]string{
+		"Date":         dateOfGmt,
+		"SessionKey":   sessionKey,
+		"Signature":    apiutil.SignatureOfHmac(sessionSecret, sessionKey, httpMethod, fullUrl, dateOfGmt),
+		"X-Request-ID": apiutil.XRequestId(),
+	}
+	logger.Verboseln("do request url: " + fullUrl)
+	respBody, err1 := p.client.Fetch(httpMethod, fullUrl, nil, headers)
+	if err1 != nil {
+		logger.Verboseln(actName+" occurs error: ", err1.Error())
+		return nil, apierror.NewApiErrorWithError(err1)
+	}
+	logger.Verboseln("response: " + string(respBody))
+
+	// handler common error
+	if apiErr := apierror.ParseAppCommonApiError(respBody); apiErr != nil {
+		return nil, apiErr
+	}
+	return respBody, nil
+}
+
+// AppFamilyGetMemberList 获取家庭云的成员列表
+func (p *PanClient) AppFamilyGetMemberList(familyId int64) (*AppFamilyMemberListResult, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/getMemberList.action?familyId=%d&%s",
+		API_URL, familyId, apiutil.PcClientInfoSuffixParam())
+	respBody, err := p.doFamilyManageRequest("AppFamilyGetMemberList", fullUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	item := &AppFamilyMemberListResult{}
+	if err := xml.Unmarshal(respBody, item); err != nil {
+		logger.Verboseln("AppFamilyGetMemberList parse response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	return item, nil
+}
+
+// AppFamilyInviteMember 邀请新成员加入家庭云，account为被邀请人的手机号
+func (p *PanClient) AppFamilyInviteMember(familyId int64, account string) *apierror.ApiError {
+	if account == "" {
+		return apierror.NewFailedApiError("请指定邀请的账号")
+	}
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/inviteNewMember.action?familyId=%d&account=%s&%s",
+		API_URL, familyId, url.QueryEscape(account), apiutil.PcClientInfoSuffixParam())
+	_, err := p.doFamilyManageRequest("AppFamilyInviteMember", fullUrl.String())
+	return err
+}
+
+// AppFamilyRemoveMember 将成员移出家庭云，只有家庭创建者有权限
+func (p *PanClient) AppFamilyRemoveMember(familyId int64, userId int64) *apierror.ApiError {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/deleteMember.action?familyId=%d&userId=%d&%s",
+		API_URL, familyId, userId, apiutil.PcClientInfoSuffixParam())
+	_, err := p.doFamilyManageRequest("AppFamilyRemoveMember", fullUrl.String())
+	return err
+}
+
+// AppFamilyRename 修改家庭云名称，即 AppFamilyInfo.RemarkName
+func (p *PanClient) AppFamilyRename(familyId int64, remarkName string) *apierror.ApiError {
+	if remarkName == "" {
+		return apierror.NewFailedApiError("家庭名称不能为空")
+	}
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/modifyFamilyInfo.action?familyId=%d&remarkName=%s&%s",
+		API_URL, familyId, url.QueryEscape(remarkName), apiutil.PcClientInfoSuffixParam())
+	_, err := p.doFamilyManageRequest("AppFamilyRename", fullUrl.String())
+	return err
+}
+
+// AppFamilyGetCapacity 获取家庭云的空间容量和使用量
+func (p *PanClient) AppFamilyGetCapacity(familyId int64) (*AppFamilyCapacityInfo, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/getFamilyCapacity.action?familyId=%d&%s",
+		API_URL, familyId, apiutil.PcClientInfoSuffixParam())
+	respBody, err := p.doFamilyManageRequest("AppFamilyGetCapacity", fullUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	item := &AppFamilyCapacityInfo{}
+	if err := xml.Unmarshal(respBody, item); err != nil {
+		logger.Verboseln("AppFamilyGetCapacity parse response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	if item.FamilyId == 0 {
+		item.FamilyId = familyId
+	}
+	return item, nil
+}
+
+// AppFamilyExit 退出家庭云，家庭创建者不能退出
+func (p *PanClient) AppFamilyExit(familyId int64) *apierror.ApiError {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/family/manage/exitFamily.action?familyId=%d&%s",
+		API_URL, familyId, apiutil.PcClientInfoSuffixParam())
+	_, err := p.doFamilyManageRequest("AppFamilyExit", fullUrl.String())
+	return err
+}
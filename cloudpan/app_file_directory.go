@@ -78,6 +78,10 @@ type (
 		PageNum uint
 		// PageSize 页大小，默认60
 		PageSize uint
+		// MediaType 媒体类型过滤，默认全部
+		MediaType MediaType
+		// Recursive 是否包含子文件夹中的文件，一般和 MediaType 一起使用
+		Recursive bool
 
 		// 默认是不返回Path路径，是否构建
 		ConstructPath bool
@@ -381,9 +385,9 @@ func (p *PanClient) AppFileList(param *AppFileListParam) (*AppFileListResult, *a
 	sessionSecret := ""
 	if param.FamilyId <= 0 {
 		// 个人云
-		fmt.Fprintf(fullUrl, "%s/listFiles.action?folderId=%s&recursive=0&fileType=0&iconOption=10&mediaAttr=0&orderBy=%s&descending=%t&pageNum=%d&pageSize=%d&%s",
+		fmt.Fprintf(fullUrl, "%s/listFiles.action?folderId=%s&recursive=%d&fileType=0&mediaType=%d&iconOption=10&mediaAttr=0&orderBy=%s&descending=%t&pageNum=%d&pageSize=%d&%s",
 			API_URL,
-			param.FileId, getAppOrderBy(param.OrderBy), param.OrderSort == OrderDesc, param.PageNum, param.PageSize,
+			param.FileId, BoolToNumber(param.Recursive), param.MediaType, getAppOrderBy(param.OrderBy), param.OrderSort == OrderDesc, param.PageNum, param.PageSize,
 			apiutil.PcClientInfoSuffixParam())
 		sessionKey = p.appToken.SessionKey
 		sessionSecret = p.appToken.SessionSecret
@@ -392,9 +396,9 @@ func (p *PanClient) AppFileList(param *AppFileListParam) (*AppFileListResult, *a
 		if param.FileId == "-11" {
 			param.FileId = ""
 		}
-		fmt.Fprintf(fullUrl, "%s/family/file/listFiles.action?folderId=%s&familyId=%d&fileType=0&iconOption=0&mediaAttr=0&orderBy=%d&descending=%t&pageNum=%d&pageSize=%d&%s",
+		fmt.Fprintf(fullUrl, "%s/family/file/listFiles.action?folderId=%s&familyId=%d&recursive=%d&fileType=0&mediaType=%d&iconOption=0&mediaAttr=0&orderBy=%d&descending=%t&pageNum=%d&pageSize=%d&%s",
 			API_URL,
-			param.FileId, param.FamilyId, param.OrderBy, param.OrderSort == OrderDesc, param.PageNum, param.PageSize,
+			param.FileId, param.FamilyId, BoolToNumber(param.Recursive), param.MediaType, param.OrderBy, param.OrderSort == OrderDesc, param.PageNum, param.PageSize,
 			apiutil.PcClientInfoSuffixParam())
 		sessionKey = p.appToken.FamilySessionKey
 		sessionSecret = p.appToken.FamilySessionSecret
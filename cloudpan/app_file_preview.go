@@ -0,0 +1,172 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/logger"
+	"strings"
+)
+
+type (
+	// ThumbnailSize 缩略图尺寸
+	ThumbnailSize int
+
+	// AppFileThumbnail 图片/视频的缩略图链接
+	AppFileThumbnail struct {
+		// SmallUrl 小尺寸缩略图
+		SmallUrl string `xml:"icon>smallUrl"`
+		// MediumUrl 中等尺寸缩略图
+		MediumUrl string `xml:"icon>mediumUrl"`
+		// LargeUrl 大尺寸缩略图
+		LargeUrl string `xml:"icon>largeUrl"`
+		// Max600Url 最大边为600像素的缩略图
+		Max600Url string `xml:"icon>max600"`
+	}
+)
+
+const (
+	// ThumbnailSizeSmall 小尺寸
+	ThumbnailSizeSmall ThumbnailSize = 1
+	// ThumbnailSizeMedium 中等尺寸
+	ThumbnailSizeMedium ThumbnailSize = 2
+	// ThumbnailSizeLarge 大尺寸
+	ThumbnailSizeLarge ThumbnailSize = 3
+)
+
+// Url 获取指定尺寸的缩略图链接
+func (t *AppFileThumbnail) Url(size ThumbnailSize) string {
+	switch size {
+	case ThumbnailSizeSmall:
+		return t.SmallUrl
+	case ThumbnailSizeMedium:
+		return t.MediumUrl
+	case ThumbnailSizeLarge:
+		return t.LargeUrl
+	}
+	return ""
+}
+
+// appFileGet 请求个人云或者家庭云的文件接口，familyId为0则请求个人云接口
+func (p *PanClient) appFileGet(actName string, familyId int64, personalPath, familyPath, query string) ([]byte, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	sessionKey := ""
+	sessionSecret := ""
+	if familyId <= 0 {
+		fmt.Fprintf(fullUrl, "%s%s?%s&%s",
+			API_URL, personalPath, query, apiutil.PcClientInfoSuffixParam())
+		sessionKey = p.appToken.SessionKey
+		sessionSecret = p.appToken.SessionSecret
+	} else {
+		fmt.Fprintf(fullUrl, "%s%s?familyId=%d&%s&%s",
+			API_URL, familyPath, familyId, query, apiutil.PcClientInfoSuffixParam())
+		sessionKey = p.appToken.FamilySessionKey
+		sessionSecret = p.appToken.FamilySessionSecret
+	}
+	httpMethod := "GET"
+	dateOfGmt := apiutil.DateOfGmtStr()
+	headers := map[string]string{
+		"Date":         dateOfGmt,
+		"SessionKey":   sessionKey,
+		"Signature":    apiutil.SignatureOfHmac(sessionSecret, sessionKey, httpMethod, fullUrl.String(), dateOfGmt),
+		"X-Request-ID": apiutil.XRequestId(),
+	}
+
+	logger.Verboseln("do request url: " + fullUrl.String())
+	respBody, err1 := p.client.Fetch(httpMethod, fullUrl.String(), nil, headers)
+	if err1 != nil {
+		logger.Verboseln(actName+" occurs error: ", err1.Error())
+		return nil, apierror.NewApiErrorWithError(err1)
+	}
+	logger.Verboseln("response: " + string(respBody))
+
+	// handler common error
+	if apiErr := apierror.ParseAppCommonApiError(respBody); apiErr != nil {
+		return nil, apiErr
+	}
+	er := &apierror.AppErrorXmlResp{}
+	if err := xml.Unmarshal(respBody, er); err == nil {
+		if er.Code != "" {
+			if er.Code == "FileNotFound" {
+				return nil, apierror.NewApiError(apierror.ApiCodeFileNotFoundCode, "文件不存在")
+			}
+			return nil, apierror.NewFailedApiError("请求出错")
+		}
+	}
+	return respBody, nil
+}
+
+// AppGetFileThumbnail 获取图片/视频文件的缩略图链接，familyId为0则获取个人云文件
+func (p *PanClient) AppGetFileThumbnail(familyId int64, fileId string) (*AppFileThumbnail, *apierror.ApiError) {
+	body, err := p.appFileGet("AppGetFileThumbnail", familyId,
+		"/getFileInfo.action", "/family/file/getFileInfo.action",
+		"fileId="+fileId+"&iconOption=5&dt=3")
+	if err != nil {
+		return nil, err
+	}
+	item := &AppFileThumbnail{}
+	if err := xml.Unmarshal(body, item); err != nil {
+		logger.Verboseln("AppGetFileThumbnail parse response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	item.SmallUrl = strings.ReplaceAll(item.SmallUrl, "&amp;", "&")
+	item.MediumUrl = strings.ReplaceAll(item.MediumUrl, "&amp;", "&")
+	item.LargeUrl = strings.ReplaceAll(item.LargeUrl, "&amp;", "&")
+	item.Max600Url = strings.ReplaceAll(item.Max600Url, "&amp;", "&")
+	return item, nil
+}
+
+// AppGetFilePreviewUrl 获取文档的在线预览链接，familyId为0则获取个人云文件
+func (p *PanClient) AppGetFilePreviewUrl(familyId int64, fileId string) (string, *apierror.ApiError) {
+	body, err := p.appFileGet("AppGetFilePreviewUrl", familyId,
+		"/getFilePreviewUrl.action", "/family/file/getFilePreviewUrl.action",
+		"fileId="+fileId)
+	if err != nil {
+		return "", err
+	}
+	type previewUrl struct {
+		XMLName xml.Name `xml:"filePreviewUrl"`
+		Url     string   `xml:",innerxml"`
+	}
+	item := &previewUrl{}
+	if err := xml.Unmarshal(body, item); err != nil {
+		logger.Verboseln("AppGetFilePreviewUrl parse response failed")
+		return "", apierror.NewApiErrorWithError(err)
+	}
+	return strings.ReplaceAll(item.Url, "&amp;", "&"), nil
+}
+
+// AppGetVideoPlayUrl 获取视频转码后的在线播放链接(m3u8)，familyId为0则获取个人云文件
+func (p *PanClient) AppGetVideoPlayUrl(familyId int64, fileId string) (string, *apierror.ApiError) {
+	body, err := p.appFileGet("AppGetVideoPlayUrl", familyId,
+		"/getNewVlcVideoPlayUrl.action", "/family/file/getNewVlcVideoPlayUrl.action",
+		"fileId="+fileId+"&type=2&dt=3")
+	if err != nil {
+		return "", err
+	}
+	type playUrl struct {
+		XMLName xml.Name `xml:"videoPlayUrl"`
+		Url     string   `xml:",innerxml"`
+	}
+	item := &playUrl{}
+	if err := xml.Unmarshal(body, item); err != nil {
+		logger.Verboseln("AppGetVideoPlayUrl parse response failed")
+		return "", apierror.NewApiErrorWithError(err)
+	}
+	return strings.ReplaceAll(item.Url, "&amp;", "&"), nil
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// AppFileSearchParam 文件搜索参数
+	AppFileSearchParam struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// FolderId 搜索的文件夹ID，默认为根目录
+		FolderId string
+		// Keyword 搜索关键字
+		Keyword string
+		// Recursive 是否搜索子文件夹
+		Recursive bool
+		// MediaType 媒体类型过滤，默认全部
+		MediaType MediaType
+
+		// MinSize 文件最小大小，0代表不限制，对文件夹无效
+		MinSize int64
+		// MaxSize 文件最大大小，0代表不限制，对文件夹无效
+		MaxSize int64
+		// ModifiedAfter 最后修改时间晚于该时间，零值代表不限制
+		ModifiedAfter time.Time
+		// ModifiedBefore 最后修改时间早于该时间，零值代表不限制
+		ModifiedBefore time.Time
+
+		// PageNum 页数量，从1开始
+		PageNum uint
+		// PageSize 页大小，默认100
+		PageSize uint
+		// ConstructPath 是否构建文件的完整路径
+		ConstructPath bool
+	}
+
+	// AppFileSearchResult 文件搜索结果
+	AppFileSearchResult struct {
+		// Count 服务端匹配的总数量，未经过大小和时间过滤
+		Count int
+		// FileList 当前页的文件列表，已经过大小和时间过滤
+		FileList AppFileList
+	}
+
+	// AppFileSearchFunc 处理搜索到的文件，返回false则停止搜索
+	AppFileSearchFunc func(file *AppFileEntity) bool
+)
+
+// NewAppFileSearchParam 创建默认搜索参数，在根目录下递归搜索
+func NewAppFileSearchParam(keyword string) *AppFileSearchParam {
+	return &AppFileSearchParam{
+		FolderId:  "-11",
+		Keyword:   keyword,
+		Recursive: true,
+		MediaType: MediaTypeDefault,
+		PageNum:   1,
+		PageSize:  100,
+	}
+}
+
+// match 服务端不支持按大小和时间过滤，在客户端进行过滤
+func (param *AppFileSearchParam) match(f *AppFileEntity) bool {
+	if f == nil {
+		return false
+	}
+	if !f.IsFolder {
+		if param.MinSize > 0 && f.FileSize < param.MinSize {
+			return false
+		}
+		if param.MaxSize > 0 && f.FileSize > param.MaxSize {
+			return false
+		}
+	}
+	if !param.ModifiedAfter.IsZero() || !param.ModifiedBefore.IsZero() {
+		lastOpTime := MustParseTime(f.LastOpTime)
+		if !param.ModifiedAfter.IsZero() && lastOpTime.Before(param.ModifiedAfter) {
+			return false
+		}
+		if !param.ModifiedBefore.IsZero() && lastOpTime.After(param.ModifiedBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// AppFileSearch 搜索文件，返回一页结果
+func (p *PanClient) AppFileSearch(param *AppFileSearchParam) (*AppFileSearchResult, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	pageNum := param.PageNum
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	pageSize := param.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	folderId := param.FolderId
+	if folderId == "" {
+		folderId = "-11"
+	}
+
+	sessionKey := ""
+	sessionSecret := ""
+	if param.FamilyId <= 0 {
+		// 个人云
+		fmt.Fprintf(fullUrl, "%s/searchFiles.action?folderId=%s&filename=%s&recursive=%d&mediaType=%d&iconOption=0&pageNum=%d&pageSize=%d&%s",
+			API_URL,
+			folderId, url.QueryEscape(param.Keyword), BoolToNumber(param.Recursive), param.MediaType, pageNum, pageSize,
+			apiutil.PcClientInfoSuffixParam())
+		sessionKey = p.appToken.SessionKey
+		sessionSecret = p.appToken.SessionSecret
+	} else {
+		// 家庭云
+		if folderId == "-11" {
+			folderId = ""
+		}
+		fmt.Fprintf(fullUrl, "%s/family/file/searchFiles.action?familyId=%d&folderId=%s&filename=%s&recursive=%d&mediaType=%d&iconOption=0&pageNum=%d&pageSize=%d&%s",
+			API_URL,
+			param.FamilyId, folderId, url.QueryEscape(param.Keyword), BoolToNumber(param.Recursive), param.MediaType, pageNum, pageSize,
+			apiutil.PcClientInfoSuffixParam())
+		sessionKey = p.appToken.FamilySessionKey
+		sessionSecret = p.appToken.FamilySessionSecret
+	}
+	httpMethod := "GET"
+	dateOfGmt := apiutil.DateOfGmtStr()
+	headers := map[string]string{
+		"Date":         dateOfGmt,
+		"SessionKey":   sessionKey,
+		"Signature":    apiutil.SignatureOfHmac(sessionSecret, sessionKey, httpMethod, fullUrl.String(), dateOfGmt),
+		"X-Request-ID": apiutil.XRequestId(),
+	}
+
+	logger.Verboseln("do request url: " + fullUrl.String())
+	respBody, err1 := p.client.Fetch(httpMethod, fullUrl.String(), nil, headers)
+	if err1 != nil {
+		logger.Verboseln("AppFileSearch occurs error: ", err1.Error())
+		return nil, apierror.NewApiErrorWithError(err1)
+	}
+	logger.Verboseln("response: " + string(respBody))
+
+	// handler common error
+	if apiErr := apierror.ParseAppCommonApiError(respBody); apiErr != nil {
+		return nil, apiErr
+	}
+
+	er := &apierror.AppErrorXmlResp{}
+	if err := xml.Unmarshal(respBody, er); err == nil {
+		if er.Code != "" {
+			if er.Code == "FileNotFound" {
+				return nil, apierror.NewApiError(apierror.ApiCodeFileNotFoundCode, "文件不存在")
+			}
+			return nil, apierror.NewFailedApiError("请求出错")
+		}
+	}
+
+	type appFileSearchResultInternal struct {
+		// 总数量
+		Count int `xml:"count"`
+		// 文件夹列表
+		FolderList AppFileList `xml:"folder"`
+		// 文件列表
+		FileList AppFileList `xml:"file"`
+	}
+	itemResult := &appFileSearchResultInternal{}
+	if err := xml.Unmarshal(respBody, itemResult); err != nil {
+		logger.Verboseln("AppFileSearch parse response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+
+	result := &AppFileSearchResult{
+		Count:    itemResult.Count,
+		FileList: AppFileList{},
+	}
+	for _, item := range itemResult.FolderList {
+		item.IsFolder = true
+		if param.match(item) {
+			result.FileList = append(result.FileList, item)
+		}
+	}
+	for _, item := range itemResult.FileList {
+		item.IsFolder = false
+		if param.match(item) {
+			result.FileList = append(result.FileList, item)
+		}
+	}
+
+	if param.ConstructPath {
+		p.constructSearchResultPath(param.FamilyId, result.FileList, map[string]string{})
+	}
+	return result, nil
+}
+
+// AppFileSearchCursor 创建文件搜索结果游标，从 param.PageNum 开始逐页获取，结果已经过大小和时间过滤
+func (p *PanClient) AppFileSearchCursor(ctx context.Context, param *AppFileSearchParam) *AppFileCursor {
+	pageParam := *param
+	if pageParam.PageNum <= 0 {
+		pageParam.PageNum = 1
+	}
+	if pageParam.PageSize <= 0 {
+		pageParam.PageSize = 100
+	}
+	// 父文件夹路径缓存，避免重复查询
+	pathCache := map[string]string{}
+	pageParam.ConstructPath = false
+
+	nextPage := int(pageParam.PageNum)
+	return &AppFileCursor{c: newListCursor(ctx, nextPage, func(int) ([]interface{}, bool, *apierror.ApiError) {
+		// 过滤后的空页不代表没有数据，继续获取下一页
+		for {
+			pageParam.PageNum = uint(nextPage)
+			r, err := p.AppFileSearch(&pageParam)
+			if err != nil {
+				return nil, false, err
+			}
+			hasMore := hasMorePage(nextPage, int(pageParam.PageSize), r.Count)
+			nextPage++
+			if len(r.FileList) == 0 && hasMore {
+				continue
+			}
+			if param.ConstructPath {
+				p.constructSearchResultPath(param.FamilyId, r.FileList, pathCache)
+			}
+			items := make([]interface{}, 0, len(r.FileList))
+			for _, f := range r.FileList {
+				items = append(items, f)
+			}
+			return items, hasMore, nil
+		}
+	})}
+}
+
+// AppFileSearchEach 自动翻页搜索所有匹配的文件，每个文件回调一次 handler，handler返回false则停止搜索
+func (p *PanClient) AppFileSearchEach(param *AppFileSearchParam, handler AppFileSearchFunc) *apierror.ApiError {
+	c := p.AppFileSearchCursor(context.Background(), param)
+	for c.Next() {
+		if handler != nil && !handler(c.Entry()) {
+			return nil
+		}
+	}
+	return c.Err()
+}
+
+// AppFileSearchAll 自动翻页搜索所有匹配的文件
+func (p *PanClient) AppFileSearchAll(param *AppFileSearchParam) (AppFileList, *apierror.ApiError) {
+	fileList := AppFileList{}
+	err := p.AppFileSearchEach(param, func(file *AppFileEntity) bool {
+		fileList = append(fileList, file)
+		return true
+	})
+	return fileList, err
+}
+
+// constructSearchResultPath 通过父文件夹ID构建搜索结果的完整路径
+func (p *PanClient) constructSearchResultPath(familyId int64, fileList AppFileList, pathCache map[string]string) {
+	for _, f := range fileList {
+		if f.Path != "" || f.ParentId == "" {
+			continue
+		}
+		parentPath, ok := pathCache[f.ParentId]
+		if !ok {
+			fp, err := p.AppFilePathById(familyId, f.ParentId)
+			if err != nil {
+				logger.Verboseln("get search result path failed: ", err.Error())
+				continue
+			}
+			parentPath = fp
+			pathCache[f.ParentId] = parentPath
+		}
+		f.Path = path.Join("/", parentPath, f.FileName)
+	}
+}
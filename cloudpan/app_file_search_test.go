@@ -0,0 +1,36 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAppFileSearchParamMatch(t *testing.T) {
+	param := NewAppFileSearchParam("test")
+	param.MinSize = 100
+	param.MaxSize = 1000
+	param.ModifiedAfter = *MustParseTime("2021-01-01 00:00:00")
+	param.ModifiedBefore = param.ModifiedAfter.Add(24 * time.Hour)
+
+	assert.True(t, param.match(&AppFileEntity{FileSize: 500, LastOpTime: "2021-01-01 12:00:00"}))
+	assert.False(t, param.match(&AppFileEntity{FileSize: 50, LastOpTime: "2021-01-01 12:00:00"}))
+	assert.False(t, param.match(&AppFileEntity{FileSize: 5000, LastOpTime: "2021-01-01 12:00:00"}))
+	assert.False(t, param.match(&AppFileEntity{FileSize: 500, LastOpTime: "2021-01-03 12:00:00"}))
+	assert.True(t, param.match(&AppFileEntity{IsFolder: true, LastOpTime: "2021-01-01 12:00:00"}))
+	assert.False(t, param.match(nil))
+}
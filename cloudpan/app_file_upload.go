@@ -43,6 +43,8 @@ type (
 		LastWrite string
 		// LocalPath 文件存储的本地绝对路径
 		LocalPath string
+		// ConflictPolicy 同名文件处理策略，默认由服务器处理
+		ConflictPolicy ConflictPolicy
 	}
 
 	AppCreateUploadFileResult struct {
@@ -57,6 +59,9 @@ type (
 		FileDataExists int `xml:"fileDataExists"`
 		// 请求的X-Request-ID
 		XRequestId string
+		// Conflict 同名文件检测结果，未指定 ConflictPolicy 时为nil。Skip为true时没有创建上传，不需要上传数据；
+		// Overwrite为true时个人云需要使用 AppUploadFileCommitOverwrite 提交，家庭云需要使用 AppFamilyUploadFileCommitOverwrite 提交
+		Conflict *ConflictResolution `xml:"-"`
 	}
 
 	AppFileUploadRange struct {
@@ -92,6 +97,16 @@ type (
 )
 
 func (p *PanClient) AppCreateUploadFile(param *AppCreateUploadFileParam) (*AppCreateUploadFileResult, *apierror.ApiError) {
+	if err := validateCreateUpload(param); err != nil {
+		return nil, err
+	}
+	conflict, err := p.resolveUploadConflict(param)
+	if err != nil {
+		return nil, err
+	}
+	if conflict != nil && conflict.Skip {
+		return &AppCreateUploadFileResult{Conflict: conflict}, nil
+	}
 	fullUrl := API_URL + "/createUploadFile.action?" + apiutil.PcClientInfoSuffixParam()
 	httpMethod := "POST"
 	dateOfGmt := apiutil.DateOfGmtStr()
@@ -137,6 +152,7 @@ func (p *PanClient) AppCreateUploadFile(param *AppCreateUploadFileParam) (*AppCr
 		return nil, apierror.NewApiErrorWithError(err)
 	}
 	item.XRequestId = requestId
+	item.Conflict = conflict
 	return item, nil
 }
 
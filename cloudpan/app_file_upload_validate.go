@@ -0,0 +1,142 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/converter"
+	"path"
+	"unicode/utf8"
+)
+
+type (
+	// UploadValidateParam 上传文件预检参数
+	UploadValidateParam struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// FileName 存储云盘的文件名
+		FileName string
+		// DirPath 存储云盘的目录绝对路径，可以为空
+		DirPath string
+		// Size 文件总大小
+		Size int64
+	}
+)
+
+const (
+	// MaxFileNameLength 文件名最大长度
+	MaxFileNameLength = 255
+	// MaxFilePathLength 文件完整路径最大长度
+	MaxFilePathLength = 1024
+)
+
+// ValidateUpload 上传文件预检，检测空间容量、单文件大小、每日上传流量以及文件名是否有效
+// 需要在计算文件MD5和上传数据之前调用，批量上传时可以先调用 GetCapacity 再对每个文件调用 ValidateUploadWithCapacity
+func (p *PanClient) ValidateUpload(param *UploadValidateParam) *apierror.ApiError {
+	// 先做本地校验，避免无效的网络请求
+	if err := ValidateUploadFileName(param); err != nil {
+		return err
+	}
+	capacity, err := p.GetCapacity()
+	if err != nil {
+		return err
+	}
+	if param.FamilyId > 0 {
+		// 容量汇总中的家庭云空间是所有家庭云的合计，需要使用目标家庭云的空间
+		familyCapacity, err := p.AppFamilyGetCapacity(param.FamilyId)
+		if err != nil {
+			return err
+		}
+		c := *capacity
+		c.Family = familyCapacitySize(familyCapacity)
+		capacity = &c
+	}
+	return ValidateUploadWithCapacity(capacity, param)
+}
+
+// familyCapacitySize 将家庭云容量转换为容量汇总中的格式
+func familyCapacitySize(c *AppFamilyCapacityInfo) CapacitySize {
+	return CapacitySize{
+		Total:     c.Capacity,
+		Used:      c.UsedSize,
+		Available: c.Available(),
+	}
+}
+
+// ValidateUploadFileName 检测上传的文件名和路径是否有效
+func ValidateUploadFileName(param *UploadValidateParam) *apierror.ApiError {
+	if param == nil || param.FileName == "" {
+		return apierror.NewApiError(apierror.ApiCodeInvalidFileName, "文件名不能为空")
+	}
+	if !apiutil.CheckFileNameValid(param.FileName) {
+		return apierror.NewApiError(apierror.ApiCodeInvalidFileName, "文件名不能包含特殊字符："+apiutil.FileNameSpecialChars)
+	}
+	if utf8.RuneCountInString(param.FileName) > MaxFileNameLength {
+		return apierror.NewApiError(apierror.ApiCodeInvalidFileName, fmt.Sprintf("文件名长度不能超过%d个字符", MaxFileNameLength))
+	}
+	if param.DirPath != "" {
+		fullPath := path.Join(param.DirPath, param.FileName)
+		if utf8.RuneCountInString(fullPath) > MaxFilePathLength {
+			return apierror.NewApiError(apierror.ApiCodeFilePathTooLong, fmt.Sprintf("文件路径长度不能超过%d个字符", MaxFilePathLength))
+		}
+	}
+	return nil
+}
+
+// validateCreateUpload 创建上传文件前检测文件名，只做本地检测。
+// 容量检测需要网络请求，并且应当在计算MD5之前进行，由调用方通过 ValidateUpload 完成
+func validateCreateUpload(param *AppCreateUploadFileParam) *apierror.ApiError {
+	return ValidateUploadFileName(&UploadValidateParam{
+		FamilyId: param.FamilyId,
+		FileName: param.FileName,
+		Size:     param.Size,
+	})
+}
+
+// ValidateUploadWithCapacity 使用已获取的容量信息进行上传文件预检，家庭云使用 capacity.Family 检测剩余空间
+func ValidateUploadWithCapacity(capacity *UserCapacityInfo, param *UploadValidateParam) *apierror.ApiError {
+	if err := ValidateUploadFileName(param); err != nil {
+		return err
+	}
+	if capacity == nil {
+		return nil
+	}
+
+	// 单文件大小，以接口返回的会员限制为准，未知则不检测
+	maxFileSize := capacity.MaxFileSize
+	if maxFileSize > 0 && param.Size > maxFileSize {
+		return apierror.NewApiError(apierror.ApiCodeFileTooLarge,
+			fmt.Sprintf("文件大小超过单文件上传限制：%s", converter.ConvertFileSize(maxFileSize, 2)))
+	}
+
+	// 剩余空间
+	space := capacity.Personal
+	if param.FamilyId > 0 {
+		space = capacity.Family
+	}
+	if space.Total > 0 && param.Size > space.Available {
+		return apierror.NewApiError(apierror.ApiCodeInsufficientCapacity,
+			fmt.Sprintf("云盘剩余空间不足，剩余：%s", converter.ConvertFileSize(space.Available, 2)))
+	}
+
+	// 每日上传流量
+	remain := capacity.UploadFlow.Remain()
+	if remain >= 0 && param.Size > remain {
+		return apierror.NewApiError(apierror.ApiCodeUserDayFlowOverLimited, "账号上传达到每日数量限额")
+	}
+	return nil
+}
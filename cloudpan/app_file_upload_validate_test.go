@@ -0,0 +1,69 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"strings"
+	"testing"
+)
+
+func TestValidateUploadFileName(t *testing.T) {
+	assert.Nil(t, ValidateUploadFileName(&UploadValidateParam{FileName: "a.txt", DirPath: "/dir"}))
+
+	err := ValidateUploadFileName(&UploadValidateParam{FileName: ""})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeInvalidFileName), err.Code)
+
+	err = ValidateUploadFileName(&UploadValidateParam{FileName: "a?.txt"})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeInvalidFileName), err.Code)
+
+	err = ValidateUploadFileName(&UploadValidateParam{FileName: strings.Repeat("a", MaxFileNameLength+1)})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeInvalidFileName), err.Code)
+
+	err = ValidateUploadFileName(&UploadValidateParam{FileName: "a.txt", DirPath: "/" + strings.Repeat("d/", MaxFilePathLength/2)})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeFilePathTooLong), err.Code)
+}
+
+func TestValidateUploadWithCapacity(t *testing.T) {
+	capacity := &UserCapacityInfo{
+		Personal:   CapacitySize{Total: 1000, Used: 900, Available: 100},
+		Family:     CapacitySize{Total: 1000, Used: 0, Available: 1000},
+		UploadFlow: UploadFlowInfo{DayFlowLimit: 500, DayFlowUsed: 0},
+	}
+	assert.Nil(t, ValidateUploadWithCapacity(capacity, &UploadValidateParam{FileName: "a.txt", Size: 100}))
+
+	err := ValidateUploadWithCapacity(capacity, &UploadValidateParam{FileName: "a.txt", Size: 101})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeInsufficientCapacity), err.Code)
+
+	// 家庭云空间充足，但超过每日上传流量
+	err = ValidateUploadWithCapacity(capacity, &UploadValidateParam{FamilyId: 1, FileName: "a.txt", Size: 600})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeUserDayFlowOverLimited), err.Code)
+
+	// 单文件大小限制未知时不检测
+	capacity.Personal.Available = 1000
+	assert.Nil(t, ValidateUploadWithCapacity(capacity, &UploadValidateParam{FileName: "a.txt", Size: 400}))
+
+	capacity.MaxFileSize = 50
+	err = ValidateUploadWithCapacity(capacity, &UploadValidateParam{FileName: "a.txt", Size: 60})
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeFileTooLarge), err.Code)
+}
+
+func TestFamilyCapacitySize(t *testing.T) {
+	size := familyCapacitySize(&AppFamilyCapacityInfo{FamilyId: 1, Capacity: 1000, UsedSize: 300})
+	assert.Equal(t, CapacitySize{Total: 1000, Used: 300, Available: 700}, size)
+	size = familyCapacitySize(&AppFamilyCapacityInfo{FamilyId: 1, Capacity: 1000, UsedSize: 1200})
+	assert.Equal(t, int64(0), size.Available)
+}
@@ -97,7 +97,7 @@ func AppLogin(username, password string) (result *AppLoginToken, error *apierror
 	result = &AppLoginToken{}
 
 	appClient.ResetCookiejar()
-	loginParams, err := appGetLoginParams()
+	loginParams, err := appGetLoginParams(appClient)
 	if err != nil {
 		logger.Verboseln("get login params error")
 		return nil, err
@@ -149,15 +149,19 @@ func AppLogin(username, password string) (result *AppLoginToken, error *apierror
 	if r.Result != 0 || r.ToUrl == "" {
 		return nil, apierror.NewFailedApiError("登录失败")
 	}
+	return appGetLoginToken(appClient, r.ToUrl, result)
+}
 
+// appGetLoginToken 登录成功后通过跳转链接获取session和accessToken
+func appGetLoginToken(client *requester.HTTPClient, toUrl string, result *AppLoginToken) (*AppLoginToken, *apierror.ApiError) {
 	fullUrl := &strings.Builder{}
 	fmt.Fprintf(fullUrl, "%s/getSessionForPC.action?clientType=%s&version=%s&channelId=%s&redirectURL=%s",
-		API_URL, "TELEMAC", "1.0.0", "web_cloud.189.cn", url.QueryEscape(r.ToUrl))
-	headers = map[string]string {
+		API_URL, "TELEMAC", "1.0.0", "web_cloud.189.cn", url.QueryEscape(toUrl))
+	headers := map[string]string {
 		"Accept": "application/json;charset=UTF-8",
 	}
 	logger.Verboseln("do request url: " + fullUrl.String())
-	body, err1 = appClient.Fetch("GET", fullUrl.String(), nil, headers)
+	body, err1 := client.Fetch("GET", fullUrl.String(), nil, headers)
 	if err1 != nil {
 		logger.Verboseln("get session info occurs error: ", err1.Error())
 		return nil, apierror.NewApiErrorWithError(err1)
@@ -195,7 +199,7 @@ func AppLogin(username, password string) (result *AppLoginToken, error *apierror
 		"Accept": "application/json",
 		"Timestamp": strconv.Itoa(timestamp),
 	}
-	body, err1 = appClient.Fetch("GET", fullUrl.String(), nil, headers)
+	body, err1 = client.Fetch("GET", fullUrl.String(), nil, headers)
 	if err1 != nil {
 		logger.Verboseln("get accessToken occurs error: ", err1.Error())
 		return nil, apierror.NewApiErrorWithError(err1)
@@ -211,7 +215,7 @@ func AppLogin(username, password string) (result *AppLoginToken, error *apierror
 	return result, nil
 }
 
-func appGetLoginParams() (params appLoginParams, error *apierror.ApiError) {
+func appGetLoginParams(client *requester.HTTPClient) (params appLoginParams, error *apierror.ApiError) {
 	header := map[string]string {
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
@@ -220,7 +224,7 @@ func appGetLoginParams() (params appLoginParams, error *apierror.ApiError) {
 	fmt.Fprintf(fullUrl, "%s/unifyLoginForPC.action?appId=%s&clientType=%s&returnURL=%s&timeStamp=%d",
 		WEB_URL, "8025431004", "10020", "https://m.cloud.189.cn/zhuanti/2020/loginErrorPc/index.html", apiutil.Timestamp())
 	logger.Verboseln("do request url: " + fullUrl.String())
-	data, err := appClient.Fetch("GET", fullUrl.String(), nil, header)
+	data, err := client.Fetch("GET", fullUrl.String(), nil, header)
 	if err != nil {
 		logger.Verboseln("login redirectURL occurs error: ", err.Error())
 		return params, apierror.NewApiErrorWithError(err)
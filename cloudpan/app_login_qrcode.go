@@ -0,0 +1,362 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/logger"
+	"github.com/tickstep/library-go/requester"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// QrCodeLoginState 二维码登录状态
+	QrCodeLoginState int
+
+	// AppQrCodeLoginSession 二维码登录会话，一个会话对应一个二维码
+	AppQrCodeLoginSession struct {
+		// Uuid 二维码内容
+		Uuid string `json:"uuid"`
+		// EncryUuid 加密的uuid，查询状态时使用
+		EncryUuid string `json:"encryuuid"`
+		// EncodeUuid 编码后的uuid，获取二维码图片时使用
+		EncodeUuid string `json:"encodeuuid"`
+
+		// client 每个会话使用独立的客户端，不影响其他登录流程的cookie
+		client      *requester.HTTPClient
+		params      appLoginParams
+		redirectUrl string
+	}
+
+	qrCodeUuidResp struct {
+		Uuid       string `json:"uuid"`
+		EncryUuid  string `json:"encryuuid"`
+		EncodeUuid string `json:"encodeuuid"`
+	}
+
+	qrCodeStateResp struct {
+		Status      int    `json:"status"`
+		RedirectUrl string `json:"redirectUrl"`
+		Msg         string `json:"msg"`
+	}
+)
+
+const (
+	// QrCodeLoginStateWaiting 等待扫码
+	QrCodeLoginStateWaiting QrCodeLoginState = -106
+	// QrCodeLoginStateScanned 已扫码，等待手机确认
+	QrCodeLoginStateScanned QrCodeLoginState = -11002
+	// QrCodeLoginStateExpired 二维码已失效
+	QrCodeLoginStateExpired QrCodeLoginState = -11001
+	// QrCodeLoginStateConfirmed 已确认登录
+	QrCodeLoginStateConfirmed QrCodeLoginState = 0
+
+	defaultQrCodePollInterval = 2 * time.Second
+	// maxQrCodeQueryRetry 查询状态连续网络请求失败的最大次数
+	maxQrCodeQueryRetry = 5
+)
+
+func (s QrCodeLoginState) String() string {
+	switch s {
+	case QrCodeLoginStateWaiting:
+		return "等待扫码"
+	case QrCodeLoginStateScanned:
+		return "已扫码"
+	case QrCodeLoginStateExpired:
+		return "已失效"
+	case QrCodeLoginStateConfirmed:
+		return "已确认"
+	default:
+		return "未知"
+	}
+}
+
+// AppQrCodeLoginStart 开始二维码登录，获取二维码uuid
+func AppQrCodeLoginStart() (*AppQrCodeLoginSession, *apierror.ApiError) {
+	client := requester.NewHTTPClient()
+	loginParams, err := appGetLoginParams(client)
+	if err != nil {
+		logger.Verboseln("get login params error")
+		return nil, err
+	}
+
+	urlStr := "https://open.e.189.cn/api/logbox/oauth2/getUUID.do"
+	formData := map[string]string{
+		"appId": "8025431004",
+	}
+	logger.Verboseln("do request url: " + urlStr)
+	body, err1 := client.Fetch("POST", urlStr, formData, loginParams.qrCodeHeaders())
+	if err1 != nil {
+		logger.Verboseln("get qrcode uuid occurs error: ", err1.Error())
+		return nil, apierror.NewApiErrorWithError(err1)
+	}
+	logger.Verboseln("response: " + string(body))
+	r := &qrCodeUuidResp{}
+	if err := json.Unmarshal(body, r); err != nil {
+		logger.Verboseln("parse qrcode uuid json error ", err)
+		return nil, apierror.NewFailedApiError(err.Error())
+	}
+	if r.Uuid == "" {
+		return nil, apierror.NewFailedApiError("获取登录二维码失败")
+	}
+	return &AppQrCodeLoginSession{
+		Uuid:       r.Uuid,
+		EncryUuid:  r.EncryUuid,
+		EncodeUuid: r.EncodeUuid,
+		client:     client,
+		params:     loginParams,
+	}, nil
+}
+
+func (l appLoginParams) qrCodeHeaders() map[string]string {
+	return map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+		"Referer":      "https://open.e.189.cn/api/logbox/oauth2/unifyAccountLogin.do",
+		"Cookie":       "LT=" + l.Lt,
+		"REQID":        l.ReqId,
+		"lt":           l.Lt,
+	}
+}
+
+// QrCodePng 获取二维码PNG图片数据
+func (s *AppQrCodeLoginSession) QrCodePng() ([]byte, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "https://open.e.189.cn/api/logbox/oauth2/image.do?uuid=%s&REQID=%s",
+		s.EncodeUuid, s.params.ReqId)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	body, err := s.client.Fetch("GET", fullUrl.String(), nil, s.params.qrCodeHeaders())
+	if err != nil {
+		logger.Verboseln("get qrcode image occurs error: ", err.Error())
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	if !bytes.HasPrefix(body, []byte("\x89PNG")) {
+		logger.Verboseln("qrcode image is not png: ", string(body))
+		return nil, apierror.NewFailedApiError("获取登录二维码图片失败")
+	}
+	return body, nil
+}
+
+// QrCodeAscii 获取二维码图片并转换成可以在终端显示的字符画
+func (s *AppQrCodeLoginSession) QrCodeAscii() (string, *apierror.ApiError) {
+	data, err := s.QrCodePng()
+	if err != nil {
+		return "", err
+	}
+	str, e := QrCodePngToAscii(data)
+	if e != nil {
+		return "", apierror.NewApiErrorWithError(e)
+	}
+	return str, nil
+}
+
+// QueryState 查询二维码当前的登录状态
+func (s *AppQrCodeLoginSession) QueryState() (QrCodeLoginState, *apierror.ApiError) {
+	state, _, err := s.queryState()
+	return state, err
+}
+
+// queryState 查询二维码当前的登录状态，transient 表示请求本身失败，可以稍后重试
+func (s *AppQrCodeLoginSession) queryState() (state QrCodeLoginState, transient bool, err *apierror.ApiError) {
+	urlStr := "https://open.e.189.cn/api/logbox/oauth2/qrcodeLoginState.do"
+	timestamp := apiutil.Timestamp()
+	formData := map[string]string{
+		"appId":      "8025431004",
+		"clientType": "10020",
+		"returnUrl":  s.params.ReturnUrl,
+		"paramId":    s.params.ParamId,
+		"uuid":       s.Uuid,
+		"encryuuid":  s.EncryUuid,
+		"timeStamp":  strconv.Itoa(timestamp),
+	}
+	logger.Verboseln("do request url: " + urlStr)
+	body, err1 := s.client.Fetch("POST", urlStr, formData, s.params.qrCodeHeaders())
+	if err1 != nil {
+		logger.Verboseln("query qrcode state occurs error: ", err1.Error())
+		return QrCodeLoginStateWaiting, true, apierror.NewApiErrorWithError(err1)
+	}
+	logger.Verboseln("response: " + string(body))
+	r := &qrCodeStateResp{}
+	if err := json.Unmarshal(body, r); err != nil {
+		logger.Verboseln("parse qrcode state json error ", err)
+		return QrCodeLoginStateWaiting, false, apierror.NewFailedApiError(err.Error())
+	}
+	state = QrCodeLoginState(r.Status)
+	switch state {
+	case QrCodeLoginStateWaiting, QrCodeLoginStateScanned, QrCodeLoginStateExpired:
+		return state, false, nil
+	case QrCodeLoginStateConfirmed:
+		if r.RedirectUrl == "" {
+			return state, false, apierror.NewFailedApiError("登录失败")
+		}
+		s.redirectUrl = r.RedirectUrl
+		return state, false, nil
+	}
+	return state, false, apierror.NewFailedApiError("未知的二维码状态：" + strconv.Itoa(r.Status) + " " + r.Msg)
+}
+
+// Token 二维码确认登录后获取登录凭证，WebLoginToken 通过 sessionKey 换取
+func (s *AppQrCodeLoginSession) Token() (*AppLoginToken, *WebLoginToken, *apierror.ApiError) {
+	if s.redirectUrl == "" {
+		return nil, nil, apierror.NewFailedApiError("二维码尚未确认登录")
+	}
+	rsaKey := &strings.Builder{}
+	fmt.Fprintf(rsaKey, "-----BEGIN PUBLIC KEY-----\n%s\n-----END PUBLIC KEY-----", s.params.jRsaKey)
+	appToken, err := appGetLoginToken(s.client, s.redirectUrl, &AppLoginToken{RsaPublicKey: rsaKey.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+	webToken := &WebLoginToken{
+		CookieLoginUser: RefreshCookieToken(appToken.SessionKey),
+	}
+	if webToken.CookieLoginUser == "" {
+		logger.Verboseln("get web token by sessionKey failed")
+		return nil, nil, apierror.NewFailedApiError("获取网页版登录凭证失败")
+	}
+	return appToken, webToken, nil
+}
+
+// Wait 轮询二维码状态直到确认登录，interval为0则使用默认间隔。每次状态变化都会回调onState，可以为nil
+// 网络请求失败时继续重试，连续失败 maxQrCodeQueryRetry 次或者ctx结束才返回，接口返回未知状态等其他错误直接返回。
+// 二维码失效时返回 ApiCodeQrCodeExpired 错误，需要重新调用 AppQrCodeLoginStart
+func (s *AppQrCodeLoginSession) Wait(ctx context.Context, interval time.Duration, onState func(state QrCodeLoginState)) (*AppLoginToken, *WebLoginToken, *apierror.ApiError) {
+	if interval <= 0 {
+		interval = defaultQrCodePollInterval
+	}
+	// 初始值不对应任何状态，保证第一次查询的状态会回调
+	lastState := QrCodeLoginState(1)
+	failedCount := 0
+	for {
+		state, transient, err := s.queryState()
+		if err != nil {
+			failedCount++
+			if !transient || failedCount >= maxQrCodeQueryRetry {
+				return nil, nil, err
+			}
+			// 网络错误等临时错误，等待后重试
+			logger.Verboseln("query qrcode state failed, retry later: ", err.Error())
+		} else {
+			failedCount = 0
+			if state != lastState && onState != nil {
+				onState(state)
+			}
+			lastState = state
+
+			switch state {
+			case QrCodeLoginStateConfirmed:
+				return s.Token()
+			case QrCodeLoginStateExpired:
+				return nil, nil, apierror.NewApiError(apierror.ApiCodeQrCodeExpired, "登录二维码已失效")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			msg := "等待扫码登录被取消: " + ctx.Err().Error()
+			if err != nil {
+				msg += "，最后一次查询状态出错: " + err.Error()
+			}
+			return nil, nil, apierror.NewFailedApiError(msg)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// QrCodePngToAscii 将二维码PNG图片转换成字符画，浅色模块使用实心方块，适合深色背景的终端
+func QrCodePngToAscii(data []byte) (string, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	str := qrCodeImageToAscii(img)
+	if str == "" {
+		return "", fmt.Errorf("无法识别二维码图片")
+	}
+	return str, nil
+}
+
+// qrCodeImageToAscii 根据左上角定位图案计算模块大小，按模块采样转换成字符画，并保留一个模块的空白边
+func qrCodeImageToAscii(img image.Image) string {
+	b := img.Bounds()
+	isDark := func(x, y int) bool {
+		r, g, bl, _ := img.At(x, y).RGBA()
+		return (r+g+bl)/3 < 0x8000
+	}
+
+	// 二维码所在区域
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X-1, b.Min.Y-1
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !isDark(x, y) {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+	if maxX < minX || maxY < minY {
+		return ""
+	}
+
+	// 定位图案的上边缘宽度为7个模块
+	run := 0
+	for x := minX; x <= maxX && isDark(x, minY); x++ {
+		run++
+	}
+	moduleSize := float64(run) / 7
+	if moduleSize < 1 {
+		return ""
+	}
+	cols := int(float64(maxX-minX+1)/moduleSize + 0.5)
+	rows := int(float64(maxY-minY+1)/moduleSize + 0.5)
+
+	const light, dark = "██", "  "
+	sb := &strings.Builder{}
+	quietLine := strings.Repeat(light, cols+2) + "\n"
+	sb.WriteString(quietLine)
+	for r := 0; r < rows; r++ {
+		sb.WriteString(light)
+		y := minY + int((float64(r)+0.5)*moduleSize)
+		for c := 0; c < cols; c++ {
+			x := minX + int((float64(c)+0.5)*moduleSize)
+			if isDark(x, y) {
+				sb.WriteString(dark)
+			} else {
+				sb.WriteString(light)
+			}
+		}
+		sb.WriteString(light + "\n")
+	}
+	sb.WriteString(quietLine)
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestQrCodePngToAscii(t *testing.T) {
+	// 9x9个模块，每个模块3像素，外围留白6像素
+	const moduleSize, margin, modules = 3, 6, 9
+	size := modules*moduleSize + margin*2
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	fill := func(mx, my int) {
+		for y := 0; y < moduleSize; y++ {
+			for x := 0; x < moduleSize; x++ {
+				img.SetGray(margin+mx*moduleSize+x, margin+my*moduleSize+y, color.Gray{})
+			}
+		}
+	}
+	for i := 0; i < 7; i++ {
+		fill(i, 0)
+	}
+	fill(8, 8)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, png.Encode(buf, img))
+	str, err := QrCodePngToAscii(buf.Bytes())
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+	assert.Equal(t, modules+2, len(lines))
+	assert.Equal(t, "██"+strings.Repeat("  ", 7)+"██████", lines[1])
+	assert.Equal(t, strings.Repeat("██", modules)+"  ██", lines[modules])
+
+	_, err = QrCodePngToAscii([]byte("not png"))
+	assert.NotNil(t, err)
+}
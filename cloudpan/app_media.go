@@ -0,0 +1,91 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+)
+
+type (
+	// AppMediaListParam 媒体分类文件列表参数
+	AppMediaListParam struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// MediaType 媒体类型，图片、音乐、视频或者文档
+		MediaType MediaType
+		// OrderBy 排序字段，默认按时间排序
+		OrderBy OrderBy
+		// OrderSort 排序顺序，默认降序
+		OrderSort OrderSort
+		// PageNum 页数量，从1开始
+		PageNum uint
+		// PageSize 页大小，默认100
+		PageSize uint
+	}
+)
+
+// NewAppMediaListParam 创建默认的媒体分类文件列表参数，按时间降序排列
+func NewAppMediaListParam(mediaType MediaType) *AppMediaListParam {
+	return &AppMediaListParam{
+		MediaType: mediaType,
+		OrderBy:   OrderByTime,
+		OrderSort: OrderDesc,
+		PageNum:   1,
+		PageSize:  100,
+	}
+}
+
+func (param *AppMediaListParam) fileListParam() *AppFileListParam {
+	fileListParam := &AppFileListParam{
+		FamilyId:  param.FamilyId,
+		FileId:    NewAppFileEntityForRootDir().FileId,
+		OrderBy:   param.OrderBy,
+		OrderSort: param.OrderSort,
+		PageNum:   param.PageNum,
+		PageSize:  param.PageSize,
+		MediaType: param.MediaType,
+		Recursive: true,
+	}
+	if fileListParam.OrderBy == 0 {
+		fileListParam.OrderBy = OrderByTime
+	}
+	if fileListParam.OrderSort == "" {
+		fileListParam.OrderSort = OrderDesc
+	}
+	if fileListParam.PageNum <= 0 {
+		fileListParam.PageNum = 1
+	}
+	if fileListParam.PageSize <= 0 {
+		fileListParam.PageSize = 100
+	}
+	return fileListParam
+}
+
+// AppMediaList 获取整个云盘中指定媒体类型的文件列表，即客户端的图片、音乐、视频、文档分类视图
+func (p *PanClient) AppMediaList(param *AppMediaListParam) (*AppFileListResult, *apierror.ApiError) {
+	if param.MediaType == MediaTypeDefault {
+		return nil, apierror.NewFailedApiError("请指定媒体类型")
+	}
+	return p.AppFileList(param.fileListParam())
+}
+
+// AppMediaListCursor 创建媒体分类文件列表游标，从 param.PageNum 开始逐页获取
+func (p *PanClient) AppMediaListCursor(ctx context.Context, param *AppMediaListParam) *AppFileCursor {
+	if param.MediaType == MediaTypeDefault {
+		return &AppFileCursor{c: newErrorListCursor(ctx, apierror.NewFailedApiError("请指定媒体类型"))}
+	}
+	return p.AppFileListCursor(ctx, param.fileListParam())
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"strconv"
+	"sync"
+)
+
+type (
+	// BatchChunkOption 批量操作分片选项
+	BatchChunkOption struct {
+		// ChunkSize 每个分片最多包含的文件数量，默认100
+		ChunkSize int
+		// Concurrency 同时执行的分片数量，默认3
+		Concurrency int
+	}
+
+	// BatchChunkFailure 执行失败的分片
+	BatchChunkFailure struct {
+		// FileIds 分片中失败的文件ID
+		FileIds []string
+		// UnknownIds 分片中结果未知的文件ID，例如服务器没有返回成功文件列表时无法确定具体哪些文件失败
+		UnknownIds []string
+		Err        *apierror.ApiError
+	}
+
+	// BatchChunkResult 分片批量操作的汇总结果
+	BatchChunkResult struct {
+		// Total 文件总数
+		Total int
+		// ChunkCount 分片数量
+		ChunkCount int
+		// SuccessCount 成功的文件数量
+		SuccessCount int
+		// Failures 失败的分片
+		Failures []*BatchChunkFailure
+	}
+)
+
+const (
+	defaultBatchChunkSize        = 100
+	defaultBatchChunkConcurrency = 3
+)
+
+// NewBatchChunkOption 创建默认的分片选项
+func NewBatchChunkOption() *BatchChunkOption {
+	return &BatchChunkOption{
+		ChunkSize:   defaultBatchChunkSize,
+		Concurrency: defaultBatchChunkConcurrency,
+	}
+}
+
+// FailedIds 所有执行失败的文件ID
+func (r *BatchChunkResult) FailedIds() []string {
+	ids := []string{}
+	for _, f := range r.Failures {
+		ids = append(ids, f.FileIds...)
+	}
+	return ids
+}
+
+// UnknownIds 所有结果未知的文件ID
+func (r *BatchChunkResult) UnknownIds() []string {
+	ids := []string{}
+	for _, f := range r.Failures {
+		ids = append(ids, f.UnknownIds...)
+	}
+	return ids
+}
+
+// IsAllSuccess 是否全部成功
+func (r *BatchChunkResult) IsAllSuccess() bool {
+	return len(r.Failures) == 0
+}
+
+func (o *BatchChunkOption) normalize() *BatchChunkOption {
+	r := NewBatchChunkOption()
+	if o != nil {
+		if o.ChunkSize > 0 {
+			r.ChunkSize = o.ChunkSize
+		}
+		if o.Concurrency > 0 {
+			r.Concurrency = o.Concurrency
+		}
+	}
+	return r
+}
+
+// chunkRanges 将长度为 total 的列表按照 size 拆分，返回每个分片的起止下标
+func chunkRanges(total, size int) [][2]int {
+	ranges := [][2]int{}
+	if size <= 0 {
+		size = defaultBatchChunkSize
+	}
+	for start := 0; start < total; start += size {
+		end := start + size
+		if end > total {
+			end = total
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// splitIdList 将文件ID列表按照 size 拆分
+func splitIdList(idList []string, size int) [][]string {
+	chunks := [][]string{}
+	for _, r := range chunkRanges(len(idList), size) {
+		chunks = append(chunks, idList[r[0]:r[1]])
+	}
+	return chunks
+}
+
+// runChunks 以有限的并发数执行所有分片，chunkFunc 返回分片中成功的数量和失败信息。
+// 每个分片开始前检查ctx，取消后剩余的分片不再执行，直接记为失败
+func runChunks(ctx context.Context, chunks [][]string, concurrency int, chunkFunc func(index int) (int, *BatchChunkFailure)) *BatchChunkResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result := &BatchChunkResult{
+		ChunkCount: len(chunks),
+		Failures:   []*BatchChunkFailure{},
+	}
+	locker := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, concurrency)
+	for i := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(index int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var successCount int
+			var failure *BatchChunkFailure
+			if err := ctx.Err(); err != nil {
+				failure = &BatchChunkFailure{FileIds: chunks[index], Err: apierror.NewFailedApiError("分片操作被取消: " + err.Error())}
+			} else {
+				successCount, failure = chunkFunc(index)
+			}
+			locker.Lock()
+			defer locker.Unlock()
+			result.SuccessCount += successCount
+			if failure != nil {
+				result.Failures = append(result.Failures, failure)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return result
+}
+
+func (r *BatchChunkResult) error() *apierror.ApiError {
+	if r.IsAllSuccess() {
+		return nil
+	}
+	failedCount, unknownCount := len(r.FailedIds()), len(r.UnknownIds())
+	if unknownCount > 0 {
+		return apierror.NewFailedApiError("部分文件操作失败，失败数量：" + strconv.Itoa(failedCount) + "，结果未知数量：" + strconv.Itoa(unknownCount))
+	}
+	if failedCount > 0 {
+		return apierror.NewFailedApiError("部分文件操作失败，失败数量：" + strconv.Itoa(failedCount))
+	}
+	return apierror.NewFailedApiError("部分分片操作失败，失败分片数量：" + strconv.Itoa(len(r.Failures)))
+}
+
+// AppDeleteFileInChunks 分片删除大量文件/文件夹，ctx取消后剩余的分片不再执行
+func (p *PanClient) AppDeleteFileInChunks(ctx context.Context, fileIdList []string, opts *BatchChunkOption) (*BatchChunkResult, *apierror.ApiError) {
+	opts = opts.normalize()
+	chunks := splitIdList(fileIdList, opts.ChunkSize)
+	result := runChunks(ctx, chunks, opts.Concurrency, func(index int) (int, *BatchChunkFailure) {
+		if _, err := p.AppDeleteFile(chunks[index]); err != nil {
+			logger.Verboseln("delete file chunk failed: ", err.Error())
+			return 0, &BatchChunkFailure{FileIds: chunks[index], Err: err}
+		}
+		return len(chunks[index]), nil
+	})
+	result.Total = len(fileIdList)
+	return result, result.error()
+}
+
+// AppMoveFileInChunks 分片移动大量文件/文件夹到目标文件夹，ctx取消后剩余的分片不再执行
+func (p *PanClient) AppMoveFileInChunks(ctx context.Context, fileIdList []string, targetFolderId string, opts *BatchChunkOption) (*BatchChunkResult, *apierror.ApiError) {
+	opts = opts.normalize()
+	chunks := splitIdList(fileIdList, opts.ChunkSize)
+	result := runChunks(ctx, chunks, opts.Concurrency, func(index int) (int, *BatchChunkFailure) {
+		if _, err := p.AppMoveFile(chunks[index], targetFolderId); err != nil {
+			logger.Verboseln("move file chunk failed: ", err.Error())
+			return 0, &BatchChunkFailure{FileIds: chunks[index], Err: err}
+		}
+		return len(chunks[index]), nil
+	})
+	result.Total = len(fileIdList)
+	return result, result.error()
+}
+
+// RunBatchTaskInChunks 将批量任务的文件列表拆分成多个任务执行，并等待所有任务完成
+func (p *PanClient) RunBatchTaskInChunks(ctx context.Context, param *BatchTaskParam, opts *BatchChunkOption, runOpts *BatchTaskRunOption) (*BatchChunkResult, *apierror.ApiError) {
+	opts = opts.normalize()
+	infoChunks := []BatchTaskInfoList{}
+	idChunks := [][]string{}
+	for _, r := range chunkRanges(len(param.TaskInfos), opts.ChunkSize) {
+		infoChunks = append(infoChunks, param.TaskInfos[r[0]:r[1]])
+		idChunks = append(idChunks, fileIdsOfBatchTaskInfoList(param.TaskInfos[r[0]:r[1]]))
+	}
+	result := runChunks(ctx, idChunks, opts.Concurrency, func(index int) (int, *BatchChunkFailure) {
+		chunkParam := &BatchTaskParam{
+			TypeFlag:       param.TypeFlag,
+			TaskInfos:      infoChunks[index],
+			TargetFolderId: param.TargetFolderId,
+			ShareId:        param.ShareId,
+		}
+		r, err := p.RunBatchTask(ctx, chunkParam, runOpts)
+		if r == nil {
+			return 0, &BatchChunkFailure{FileIds: idChunks[index], Err: err}
+		}
+		return batchTaskChunkOutcome(r, err)
+	})
+	result.Total = len(param.TaskInfos)
+	return result, result.error()
+}
+
+// batchTaskChunkOutcome 汇总单个分片任务的结果。服务器没有返回完整的成功文件列表时，
+// 结果未知的文件按照任务的成功数量计数，但是不能确定具体哪些文件失败，记为结果未知
+func batchTaskChunkOutcome(r *BatchTaskResult, err *apierror.ApiError) (int, *BatchChunkFailure) {
+	successCount := 0
+	failedIds := []string{}
+	unknownIds := []string{}
+	for _, fr := range r.FileResults {
+		switch fr.Outcome {
+		case BatchTaskFileOutcomeSuccess:
+			successCount++
+		case BatchTaskFileOutcomeFailed:
+			failedIds = append(failedIds, fr.TaskInfo.FileId)
+		default:
+			unknownIds = append(unknownIds, fr.TaskInfo.FileId)
+		}
+	}
+	finished := r.TaskStatus == BatchTaskStatusOk || r.TaskStatus == BatchTaskStatusFailed
+	if finished && len(unknownIds) > 0 && r.SuccessedCount > successCount {
+		extra := r.SuccessedCount - successCount
+		if extra > len(unknownIds) {
+			extra = len(unknownIds)
+		}
+		successCount += extra
+		if extra == len(unknownIds) {
+			// 结果未知的文件全部成功
+			unknownIds = []string{}
+		}
+	}
+	if err == nil && len(failedIds) == 0 && len(unknownIds) == 0 {
+		return successCount, nil
+	}
+	if err == nil {
+		err = apierror.NewFailedApiError("批量任务部分文件处理失败")
+	}
+	return successCount, &BatchChunkFailure{FileIds: failedIds, UnknownIds: unknownIds, Err: err}
+}
+
+func fileIdsOfBatchTaskInfoList(infoList BatchTaskInfoList) []string {
+	ids := []string{}
+	for _, info := range infoList {
+		ids = append(ids, info.FileId)
+	}
+	return ids
+}
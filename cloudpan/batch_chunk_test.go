@@ -0,0 +1,101 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"strconv"
+	"testing"
+)
+
+func TestChunkRanges(t *testing.T) {
+	assert.Equal(t, [][2]int{{0, 3}, {3, 6}, {6, 7}}, chunkRanges(7, 3))
+	assert.Equal(t, 0, len(chunkRanges(0, 3)))
+
+	ids := []string{}
+	for i := 0; i < 7; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+	chunks := splitIdList(ids, 3)
+	assert.Equal(t, 3, len(chunks))
+	assert.Equal(t, []string{"6"}, chunks[2])
+}
+
+func TestRunChunks(t *testing.T) {
+	chunks := splitIdList([]string{"1", "2", "3", "4", "5"}, 2)
+	r := runChunks(context.Background(), chunks, 2, func(index int) (int, *BatchChunkFailure) {
+		if index == 1 {
+			return 0, &BatchChunkFailure{FileIds: chunks[index], Err: apierror.NewFailedApiError("failed")}
+		}
+		if index == 2 {
+			// 失败但是不清楚具体哪个文件失败
+			return 0, &BatchChunkFailure{Err: apierror.NewFailedApiError("failed")}
+		}
+		return len(chunks[index]), nil
+	})
+	assert.Equal(t, 3, r.ChunkCount)
+	assert.Equal(t, 2, r.SuccessCount)
+	assert.Equal(t, []string{"3", "4"}, r.FailedIds())
+	assert.Equal(t, 2, len(r.Failures))
+	assert.False(t, r.IsAllSuccess())
+}
+
+func TestRunChunksCancelled(t *testing.T) {
+	chunks := splitIdList([]string{"1", "2", "3"}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	called := 0
+	r := runChunks(ctx, chunks, 1, func(index int) (int, *BatchChunkFailure) {
+		called++
+		cancel()
+		return len(chunks[index]), nil
+	})
+	assert.Equal(t, 1, called)
+	assert.Equal(t, 1, r.SuccessCount)
+	assert.Equal(t, []string{"2", "3"}, r.FailedIds())
+}
+
+func TestBatchTaskChunkOutcome(t *testing.T) {
+	fileResults := func(outcomes ...BatchTaskFileOutcome) []*BatchTaskFileResult {
+		r := []*BatchTaskFileResult{}
+		for i, o := range outcomes {
+			r = append(r, &BatchTaskFileResult{TaskInfo: &BatchTaskInfo{FileId: strconv.Itoa(i + 1)}, Outcome: o})
+		}
+		return r
+	}
+	U, S, F := BatchTaskFileOutcomeUnknown, BatchTaskFileOutcomeSuccess, BatchTaskFileOutcomeFailed
+
+	successCount, failure := batchTaskChunkOutcome(&BatchTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 2, FileResults: fileResults(S, S)}, nil)
+	assert.Equal(t, 2, successCount)
+	assert.Nil(t, failure)
+
+	// 没有成功文件列表，成功数量按照任务结果计数，具体文件结果未知
+	failedErr := apierror.NewFailedApiError("failed")
+	successCount, failure = batchTaskChunkOutcome(&BatchTaskResult{TaskStatus: BatchTaskStatusFailed, SuccessedCount: 2, FailedCount: 1, FileResults: fileResults(U, U, U)}, failedErr)
+	assert.Equal(t, 2, successCount)
+	assert.Equal(t, 0, len(failure.FileIds))
+	assert.Equal(t, []string{"1", "2", "3"}, failure.UnknownIds)
+
+	successCount, failure = batchTaskChunkOutcome(&BatchTaskResult{TaskStatus: BatchTaskStatusFailed, SuccessedCount: 1, FailedCount: 1, FileResults: fileResults(S, F)}, failedErr)
+	assert.Equal(t, 1, successCount)
+	assert.Equal(t, []string{"2"}, failure.FileIds)
+	assert.Equal(t, 0, len(failure.UnknownIds))
+
+	// 任务未完成
+	successCount, failure = batchTaskChunkOutcome(&BatchTaskResult{TaskStatus: BatchTaskStatusRunning, SuccessedCount: 1, FileResults: fileResults(S, U)}, failedErr)
+	assert.Equal(t, 1, successCount)
+	assert.Equal(t, []string{"2"}, failure.UnknownIds)
+}
@@ -27,13 +27,15 @@ type (
 	// TaskInfo 任务信息
 	BatchTaskInfo struct {
 		// FileId 文件ID
-		FileId string `json:"fileId"`
+		FileId string `json:"fileId" xml:"fileId"`
 		// FileName 文件名
-		FileName string `json:"fileName"`
+		FileName string `json:"fileName" xml:"fileName"`
 		// IsFolder 是否是文件夹，0-否，1-是
-		IsFolder int `json:"isFolder"`
+		IsFolder int `json:"isFolder" xml:"isFolder"`
 		// SrcParentId 文件所在父目录ID
-		SrcParentId string `json:"srcParentId"`
+		SrcParentId string `json:"srcParentId" xml:"srcParentId"`
+		// DealWay 同名冲突处理方式，仅在提交冲突处理时使用
+		DealWay BatchTaskDealWay `json:"dealWay,omitempty" xml:"dealWay,omitempty"`
 	}
 
 	BatchTaskInfoList []*BatchTaskInfo
@@ -54,19 +56,37 @@ type (
 		SuccessedCount      int     `json:"successedCount" xml:"successedCount"`
 		SuccessedFileIdList []int64 `json:"successedFileIdList" xml:"successedFileIdList"`
 		TaskId              string  `json:"taskId" xml:"taskId"`
-		// TaskStatus 任务状态， 4-成功
+		// TaskStatus 任务状态，1-初始化，2-存在冲突，3-执行中，4-完成
 		TaskStatus BatchTaskStatus `json:"taskStatus" xml:"taskStatus"`
 	}
 
-	BatchTaskStatus int
-	BatchTaskType   string
+	// BatchTaskConflictInfo 批量任务冲突文件信息
+	BatchTaskConflictInfo struct {
+		TaskId string `json:"taskId" xml:"taskId"`
+		// TargetFolderId 任务目标文件夹ID
+		TargetFolderId string `json:"targetFolderId" xml:"targetFolderId"`
+		// TaskInfos 存在同名冲突的文件列表
+		TaskInfos BatchTaskInfoList `json:"taskInfos" xml:"taskInfos>taskInfo"`
+	}
+
+	BatchTaskStatus  int
+	BatchTaskType    string
+	BatchTaskDealWay int
 )
 
 const (
-	// BatchTaskStatusNotAction 无需任何操作
+	// BatchTaskStatusInit 初始化
+	BatchTaskStatusInit BatchTaskStatus = 1
+	// BatchTaskStatusNotAction 无需任何操作，任务存在同名文件冲突等待处理
 	BatchTaskStatusNotAction BatchTaskStatus = 2
-	// BatchTaskStatusOk 成功
+	// BatchTaskStatusConflict 存在同名文件冲突，和 BatchTaskStatusNotAction 一致
+	BatchTaskStatusConflict = BatchTaskStatusNotAction
+	// BatchTaskStatusRunning 执行中
+	BatchTaskStatusRunning BatchTaskStatus = 3
+	// BatchTaskStatusOk 执行完成，是否有文件失败需要看 FailedCount 和 SkipCount
 	BatchTaskStatusOk BatchTaskStatus = 4
+	// BatchTaskStatusFailed 执行完成但存在失败或者跳过的文件，服务器不会返回该状态，由 CheckTaskResult.Status 计算得到
+	BatchTaskStatusFailed BatchTaskStatus = -1
 
 	// BatchTaskTypeDelete 删除文件任务
 	BatchTaskTypeDelete BatchTaskType = "DELETE"
@@ -77,30 +97,83 @@ const (
 
 	// BatchTaskTypeRecycleRestore 还原回收站文件
 	BatchTaskTypeRecycleRestore BatchTaskType = "RESTORE"
+	// BatchTaskTypeClearRecycle 彻底删除回收站指定文件
+	BatchTaskTypeClearRecycle BatchTaskType = "CLEAR_RECYCLE"
 
 	// BatchTaskTypeShareSave 转录分享
 	BatchTaskTypeShareSave BatchTaskType = "SHARE_SAVE"
+
+	// BatchTaskDealWaySkip 冲突处理：跳过
+	BatchTaskDealWaySkip BatchTaskDealWay = 1
+	// BatchTaskDealWayKeepBoth 冲突处理：保留两者，新文件自动重命名
+	BatchTaskDealWayKeepBoth BatchTaskDealWay = 2
+	// BatchTaskDealWayOverwrite 冲突处理：覆盖
+	BatchTaskDealWayOverwrite BatchTaskDealWay = 3
 )
 
-func (p *PanClient) CreateBatchTask(param *BatchTaskParam) (taskId string, error *apierror.ApiError) {
-	fullUrl := &strings.Builder{}
-	//fmt.Fprintf(fullUrl, "%s/createBatchTask.action", WEB_URL)
-	fmt.Fprintf(fullUrl, "%s/api/open/batch/createBatchTask.action", WEB_URL)
-	logger.Verboseln("do request url: " + fullUrl.String())
-	taskInfosStr, err := json.Marshal(param.TaskInfos)
-	var postData map[string]string
-	if BatchTaskTypeDelete == param.TypeFlag || BatchTaskTypeRecycleRestore == param.TypeFlag {
-		postData = map[string]string{
+// Failed 任务是否执行完成但存在失败或者跳过的文件
+func (r *CheckTaskResult) Failed() bool {
+	return r.TaskStatus == BatchTaskStatusOk && r.FailedCount+r.SkipCount > 0
+}
+
+// Status 任务状态，执行完成但存在失败或者跳过的文件时返回 BatchTaskStatusFailed
+func (r *CheckTaskResult) Status() BatchTaskStatus {
+	if r.Failed() {
+		return BatchTaskStatusFailed
+	}
+	return r.TaskStatus
+}
+
+func (s BatchTaskStatus) String() string {
+	switch s {
+	case BatchTaskStatusInit:
+		return "初始化"
+	case BatchTaskStatusConflict:
+		return "存在冲突"
+	case BatchTaskStatusRunning:
+		return "执行中"
+	case BatchTaskStatusOk:
+		return "完成"
+	case BatchTaskStatusFailed:
+		return "失败"
+	default:
+		return "未知"
+	}
+}
+
+func (d BatchTaskDealWay) String() string {
+	switch d {
+	case BatchTaskDealWaySkip:
+		return "跳过"
+	case BatchTaskDealWayKeepBoth:
+		return "保留两者"
+	case BatchTaskDealWayOverwrite:
+		return "覆盖"
+	default:
+		return "未知"
+	}
+}
+
+// batchTaskPostData 根据任务类型构建创建任务的请求参数
+func batchTaskPostData(param *BatchTaskParam) (map[string]string, *apierror.ApiError) {
+	taskInfos := param.TaskInfos
+	if taskInfos == nil {
+		taskInfos = BatchTaskInfoList{}
+	}
+	taskInfosStr, _ := json.Marshal(taskInfos)
+	switch param.TypeFlag {
+	case BatchTaskTypeDelete, BatchTaskTypeRecycleRestore, BatchTaskTypeClearRecycle:
+		return map[string]string{
 			"type":      string(param.TypeFlag),
 			"taskInfos": string(taskInfosStr),
-		}
-	} else if BatchTaskTypeCopy == param.TypeFlag || BatchTaskTypeMove == param.TypeFlag {
-		postData = map[string]string{
+		}, nil
+	case BatchTaskTypeCopy, BatchTaskTypeMove:
+		return map[string]string{
 			"type":           string(param.TypeFlag),
 			"taskInfos":      string(taskInfosStr),
 			"targetFolderId": param.TargetFolderId,
-		}
-	} else if BatchTaskTypeShareSave == param.TypeFlag {
+		}, nil
+	case BatchTaskTypeShareSave:
 		type batchTaskShareSaveInfo struct {
 			// FileId 文件ID
 			FileId string `json:"fileId"`
@@ -110,7 +183,7 @@ func (p *PanClient) CreateBatchTask(param *BatchTaskParam) (taskId string, error
 			IsFolder int `json:"isFolder"`
 		}
 		tsl := []*batchTaskShareSaveInfo{}
-		for _, item := range param.TaskInfos {
+		for _, item := range taskInfos {
 			tsl = append(tsl, &batchTaskShareSaveInfo{
 				FileId:   item.FileId,
 				FileName: item.FileName,
@@ -118,14 +191,24 @@ func (p *PanClient) CreateBatchTask(param *BatchTaskParam) (taskId string, error
 			})
 		}
 		taskInfosStr, _ = json.Marshal(tsl)
-		postData = map[string]string{
+		return map[string]string{
 			"type":           string(param.TypeFlag),
 			"taskInfos":      string(taskInfosStr),
 			"targetFolderId": param.TargetFolderId,
 			"shareId":        strconv.FormatInt(param.ShareId, 10),
-		}
-	} else {
-		return "", apierror.NewFailedApiError("不支持的操作")
+		}, nil
+	}
+	return nil, apierror.NewFailedApiError("不支持的操作")
+}
+
+func (p *PanClient) CreateBatchTask(param *BatchTaskParam) (taskId string, error *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	//fmt.Fprintf(fullUrl, "%s/createBatchTask.action", WEB_URL)
+	fmt.Fprintf(fullUrl, "%s/api/open/batch/createBatchTask.action", WEB_URL)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	postData, apiErr := batchTaskPostData(param)
+	if apiErr != nil {
+		return "", apiErr
 	}
 
 	//body, err := p.client.DoPost(fullUrl.String(), postData)
@@ -182,3 +265,60 @@ func (p *PanClient) CheckBatchTask(typeFlag BatchTaskType, taskId string) (resul
 	}
 	return item, nil
 }
+
+// GetConflictTaskInfo 获取批量任务中存在同名冲突的文件列表
+func (p *PanClient) GetConflictTaskInfo(typeFlag BatchTaskType, taskId string) (result *BatchTaskConflictInfo, error *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/api/open/batch/getConflictTaskInfo.action", WEB_URL)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	postData := map[string]string{
+		"type":   string(typeFlag),
+		"taskId": taskId,
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8",
+		"accept":       "application/json;charset=UTF-8",
+	}
+	body, err := p.client.Fetch("POST", fullUrl.String(), postData, headers)
+	if err != nil {
+		logger.Verboseln("GetConflictTaskInfo failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	item := &BatchTaskConflictInfo{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("GetConflictTaskInfo response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	return item, nil
+}
+
+// ManageBatchTask 提交批量任务的冲突处理方式，taskInfos 中每个文件需要指定 DealWay
+func (p *PanClient) ManageBatchTask(typeFlag BatchTaskType, taskId, targetFolderId string, taskInfos BatchTaskInfoList) *apierror.ApiError {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/api/open/batch/manageBatchTask.action", WEB_URL)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	taskInfosStr, _ := json.Marshal(taskInfos)
+	postData := map[string]string{
+		"type":           string(typeFlag),
+		"taskId":         taskId,
+		"targetFolderId": targetFolderId,
+		"taskInfos":      string(taskInfosStr),
+	}
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8",
+		"accept":       "application/json;charset=UTF-8",
+	}
+	body, err := p.client.Fetch("POST", fullUrl.String(), postData, headers)
+	if err != nil {
+		logger.Verboseln("ManageBatchTask failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	item := &apierror.ErrorResp{}
+	if err := json.Unmarshal(body, item); err == nil {
+		if item.ErrorCode != "" {
+			logger.Verboseln("ManageBatchTask response failed", item)
+			return apierror.NewFailedApiError(item.ErrorMsg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"strconv"
+	"time"
+)
+
+type (
+	// BatchTaskProgressFunc 批量任务进度回调
+	BatchTaskProgressFunc func(progress *CheckTaskResult)
+
+	// BatchTaskConflictFunc 批量任务冲突处理回调，任务处于冲突状态时调用，处理完成后任务继续执行
+	// 返回错误则终止等待
+	BatchTaskConflictFunc func(ctx context.Context, familyId int64, param *BatchTaskParam, progress *CheckTaskResult) *apierror.ApiError
+
+	// BatchTaskConflictDecideFunc 决定冲突文件的处理方式
+	BatchTaskConflictDecideFunc func(info *BatchTaskInfo) BatchTaskDealWay
+
+	// BatchTaskRunOption 批量任务执行选项
+	BatchTaskRunOption struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// PollInterval 首次查询任务状态的间隔，默认500ms
+		PollInterval time.Duration
+		// MaxPollInterval 查询任务状态的最大间隔，默认5s
+		MaxPollInterval time.Duration
+		// Timeout 等待任务完成的超时时间，0代表不限制
+		Timeout time.Duration
+		// OnProgress 任务进度回调
+		OnProgress BatchTaskProgressFunc
+		// OnConflict 任务冲突处理回调，为nil则遇到冲突返回错误
+		OnConflict BatchTaskConflictFunc
+	}
+
+	// BatchTaskFileOutcome 批量任务中单个文件的处理结果
+	BatchTaskFileOutcome int
+
+	// BatchTaskFileResult 批量任务中单个文件的结果
+	BatchTaskFileResult struct {
+		TaskInfo *BatchTaskInfo
+		Outcome  BatchTaskFileOutcome
+	}
+
+	// BatchTaskResult 批量任务执行结果
+	BatchTaskResult struct {
+		TaskId         string
+		TaskStatus     BatchTaskStatus
+		SubTaskCount   int
+		SuccessedCount int
+		FailedCount    int
+		SkipCount      int
+		// FileResults 每个文件的处理结果
+		FileResults []*BatchTaskFileResult
+	}
+)
+
+const (
+	// BatchTaskFileOutcomeUnknown 未知，任务未完成或者只返回了部分成功文件列表
+	BatchTaskFileOutcomeUnknown BatchTaskFileOutcome = 0
+	// BatchTaskFileOutcomeSuccess 成功
+	BatchTaskFileOutcomeSuccess BatchTaskFileOutcome = 1
+	// BatchTaskFileOutcomeFailed 失败或者被跳过
+	BatchTaskFileOutcomeFailed BatchTaskFileOutcome = 2
+
+	defaultBatchTaskPollInterval    = 500 * time.Millisecond
+	defaultBatchTaskMaxPollInterval = 5 * time.Second
+)
+
+// NewBatchTaskRunOption 创建默认的批量任务执行选项
+func NewBatchTaskRunOption() *BatchTaskRunOption {
+	return &BatchTaskRunOption{
+		PollInterval:    defaultBatchTaskPollInterval,
+		MaxPollInterval: defaultBatchTaskMaxPollInterval,
+	}
+}
+
+func (o BatchTaskFileOutcome) String() string {
+	switch o {
+	case BatchTaskFileOutcomeSuccess:
+		return "成功"
+	case BatchTaskFileOutcomeFailed:
+		return "失败"
+	default:
+		return "未知"
+	}
+}
+
+// RunBatchTask 创建批量任务并等待任务完成，期间按退避间隔查询任务状态并回调进度
+func (p *PanClient) RunBatchTask(ctx context.Context, param *BatchTaskParam, opts *BatchTaskRunOption) (*BatchTaskResult, *apierror.ApiError) {
+	if opts == nil {
+		opts = NewBatchTaskRunOption()
+	}
+	if ctx != nil && ctx.Err() != nil {
+		return nil, apierror.NewFailedApiError("批量任务被取消: " + ctx.Err().Error())
+	}
+	var taskId string
+	var err *apierror.ApiError
+	if opts.FamilyId > 0 {
+		taskId, err = p.AppCreateBatchTask(opts.FamilyId, param)
+	} else {
+		taskId, err = p.CreateBatchTask(param)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if taskId == "" {
+		return nil, apierror.NewFailedApiError("创建批量任务失败")
+	}
+	return p.WaitBatchTask(ctx, param, taskId, opts)
+}
+
+// WaitBatchTask 等待已创建的批量任务完成。任务完成但存在失败或者跳过的文件时，同时返回结果和错误
+func (p *PanClient) WaitBatchTask(ctx context.Context, param *BatchTaskParam, taskId string, opts *BatchTaskRunOption) (*BatchTaskResult, *apierror.ApiError) {
+	if opts == nil {
+		opts = NewBatchTaskRunOption()
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultBatchTaskPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+
+	for {
+		var progress *CheckTaskResult
+		var err *apierror.ApiError
+		if opts.FamilyId > 0 {
+			progress, err = p.AppCheckBatchTask(param.TypeFlag, taskId)
+		} else {
+			progress, err = p.CheckBatchTask(param.TypeFlag, taskId)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+
+		if progress.TaskStatus == BatchTaskStatusConflict {
+			if opts.OnConflict == nil {
+				return newBatchTaskResult(taskId, param, progress), apierror.NewFailedApiError("批量任务存在同名文件冲突")
+			}
+			if err := opts.OnConflict(ctx, opts.FamilyId, param, progress); err != nil {
+				return newBatchTaskResult(taskId, param, progress), err
+			}
+			// 冲突处理后重新开始计算间隔
+			interval = opts.PollInterval
+			if interval <= 0 {
+				interval = defaultBatchTaskPollInterval
+			}
+		} else if done, err := batchTaskDone(progress); done {
+			return newBatchTaskResult(taskId, param, progress), err
+		}
+
+		logger.Verboseln("batch task ", taskId, " status: ", progress.TaskStatus)
+		select {
+		case <-ctx.Done():
+			return newBatchTaskResult(taskId, param, progress), apierror.NewFailedApiError("等待批量任务完成被取消: " + ctx.Err().Error())
+		case <-time.After(interval):
+		}
+		interval = interval * 3 / 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// BatchTaskConflictDealWayAll 所有冲突文件使用相同的处理方式
+func BatchTaskConflictDealWayAll(dealWay BatchTaskDealWay) BatchTaskConflictDecideFunc {
+	return func(info *BatchTaskInfo) BatchTaskDealWay {
+		return dealWay
+	}
+}
+
+// BatchTaskConflictResolver 创建冲突处理回调，可直接作为 BatchTaskRunOption.OnConflict 使用。
+// 查询任务的冲突文件列表，按 decide 的结果逐个指定处理方式后提交
+func (p *PanClient) BatchTaskConflictResolver(decide BatchTaskConflictDecideFunc) BatchTaskConflictFunc {
+	if decide == nil {
+		decide = BatchTaskConflictDealWayAll(BatchTaskDealWaySkip)
+	}
+	return func(ctx context.Context, familyId int64, param *BatchTaskParam, progress *CheckTaskResult) *apierror.ApiError {
+		var conflict *BatchTaskConflictInfo
+		var err *apierror.ApiError
+		if familyId > 0 {
+			conflict, err = p.AppGetConflictTaskInfo(familyId, param.TypeFlag, progress.TaskId)
+		} else {
+			conflict, err = p.GetConflictTaskInfo(param.TypeFlag, progress.TaskId)
+		}
+		if err != nil {
+			return err
+		}
+
+		taskInfos := BatchTaskInfoList{}
+		for _, info := range conflict.TaskInfos {
+			if info == nil {
+				continue
+			}
+			info.DealWay = decide(info)
+			taskInfos = append(taskInfos, info)
+		}
+		targetFolderId := conflict.TargetFolderId
+		if targetFolderId == "" {
+			targetFolderId = param.TargetFolderId
+		}
+		if familyId > 0 {
+			return p.AppManageBatchTask(familyId, param.TypeFlag, progress.TaskId, targetFolderId, taskInfos)
+		}
+		return p.ManageBatchTask(param.TypeFlag, progress.TaskId, targetFolderId, taskInfos)
+	}
+}
+
+// batchTaskDone 判断任务是否已经结束，任务完成但存在失败或者跳过的文件时返回错误
+func batchTaskDone(progress *CheckTaskResult) (bool, *apierror.ApiError) {
+	if progress.TaskStatus != BatchTaskStatusOk {
+		return false, nil
+	}
+	if progress.Failed() {
+		return true, apierror.NewFailedApiError(fmt.Sprintf("批量任务部分文件执行失败，失败%d个，跳过%d个", progress.FailedCount, progress.SkipCount))
+	}
+	return true, nil
+}
+
+func newBatchTaskResult(taskId string, param *BatchTaskParam, progress *CheckTaskResult) *BatchTaskResult {
+	r := &BatchTaskResult{
+		TaskId:         taskId,
+		TaskStatus:     progress.Status(),
+		SubTaskCount:   progress.SubTaskCount,
+		SuccessedCount: progress.SuccessedCount,
+		FailedCount:    progress.FailedCount,
+		SkipCount:      progress.SkipCount,
+		FileResults:    []*BatchTaskFileResult{},
+	}
+	successed := map[string]bool{}
+	for _, id := range progress.SuccessedFileIdList {
+		successed[strconv.FormatInt(id, 10)] = true
+	}
+	done := progress.TaskStatus == BatchTaskStatusOk
+	// 成功文件列表完整时，任务完成后不在列表中的文件就是失败或者被跳过的文件
+	completeList := len(progress.SuccessedFileIdList) >= progress.SuccessedCount
+	for _, info := range param.TaskInfos {
+		outcome := BatchTaskFileOutcomeUnknown
+		switch {
+		case successed[info.FileId]:
+			outcome = BatchTaskFileOutcomeSuccess
+		case !done:
+			// 任务未完成，未成功的文件结果未知
+		case progress.FailedCount+progress.SkipCount == 0:
+			outcome = BatchTaskFileOutcomeSuccess
+		case completeList:
+			outcome = BatchTaskFileOutcomeFailed
+		}
+		r.FileResults = append(r.FileResults, &BatchTaskFileResult{
+			TaskInfo: info,
+			Outcome:  outcome,
+		})
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBatchTaskDone(t *testing.T) {
+	cases := []struct {
+		progress CheckTaskResult
+		done     bool
+		hasErr   bool
+	}{
+		{CheckTaskResult{TaskStatus: BatchTaskStatusInit}, false, false},
+		{CheckTaskResult{TaskStatus: BatchTaskStatusRunning, FailedCount: 1}, false, false},
+		{CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 2}, true, false},
+		{CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 1, FailedCount: 1}, true, true},
+		{CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 1, SkipCount: 1}, true, true},
+	}
+	for i, c := range cases {
+		done, err := batchTaskDone(&c.progress)
+		assert.Equal(t, c.done, done, "case %d", i)
+		assert.Equal(t, c.hasErr, err != nil, "case %d", i)
+		assert.Equal(t, c.hasErr, c.progress.Failed(), "case %d", i)
+		assert.Equal(t, c.hasErr, c.progress.Status() == BatchTaskStatusFailed, "case %d", i)
+	}
+}
+
+func TestNewBatchTaskResult(t *testing.T) {
+	param := &BatchTaskParam{
+		TypeFlag: BatchTaskTypeDelete,
+		TaskInfos: BatchTaskInfoList{
+			{FileId: "1"}, {FileId: "2"}, {FileId: "3"},
+		},
+	}
+	S, F, U := BatchTaskFileOutcomeSuccess, BatchTaskFileOutcomeFailed, BatchTaskFileOutcomeUnknown
+	cases := []struct {
+		name     string
+		progress CheckTaskResult
+		outcomes []BatchTaskFileOutcome
+	}{
+		{"全部成功", CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 3}, []BatchTaskFileOutcome{S, S, S}},
+		{"全部失败", CheckTaskResult{TaskStatus: BatchTaskStatusOk, FailedCount: 3}, []BatchTaskFileOutcome{F, F, F}},
+		{"部分失败，成功列表完整", CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 1, FailedCount: 1, SkipCount: 1, SuccessedFileIdList: []int64{2}}, []BatchTaskFileOutcome{F, S, F}},
+		{"部分失败，没有成功列表", CheckTaskResult{TaskStatus: BatchTaskStatusOk, SuccessedCount: 2, FailedCount: 1}, []BatchTaskFileOutcome{U, U, U}},
+		{"执行中", CheckTaskResult{TaskStatus: BatchTaskStatusRunning, SuccessedCount: 1, SuccessedFileIdList: []int64{1}}, []BatchTaskFileOutcome{S, U, U}},
+	}
+	for _, c := range cases {
+		r := newBatchTaskResult("task", param, &c.progress)
+		outcomes := []BatchTaskFileOutcome{}
+		for _, fr := range r.FileResults {
+			outcomes = append(outcomes, fr.Outcome)
+		}
+		assert.Equal(t, c.outcomes, outcomes, c.name)
+		assert.Equal(t, c.progress.Status(), r.TaskStatus, c.name)
+	}
+}
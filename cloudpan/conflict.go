@@ -0,0 +1,444 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/logger"
+)
+
+type (
+	// ConflictPolicy 目标位置存在同名文件时的处理策略
+	ConflictPolicy int
+
+	// ConflictResolution 同名文件冲突检测结果
+	ConflictResolution struct {
+		// Name 最终使用的文件名
+		Name string
+		// Existing 目标文件夹中已存在的同名文件，没有冲突则为nil
+		Existing *AppFileEntity
+		// Skip 是否跳过本次操作
+		Skip bool
+		// Overwrite 是否需要覆盖已存在的同名文件
+		Overwrite bool
+	}
+)
+
+const (
+	// ConflictPolicyDefault 不做检测，使用服务器默认处理方式
+	ConflictPolicyDefault ConflictPolicy = 0
+	// ConflictPolicyFail 存在同名文件则返回错误
+	ConflictPolicyFail ConflictPolicy = 1
+	// ConflictPolicyOverwrite 覆盖同名文件，同名文件会被删除进入回收站
+	ConflictPolicyOverwrite ConflictPolicy = 2
+	// ConflictPolicySkip 存在同名文件则跳过
+	ConflictPolicySkip ConflictPolicy = 3
+	// ConflictPolicyAutoRename 自动重命名，格式为 "name (1).ext"
+	ConflictPolicyAutoRename ConflictPolicy = 4
+)
+
+// ResolveConflict 检测目标文件夹中是否存在同名文件，并根据策略给出处理结果
+// 上传文件时设置 AppCreateUploadFileParam.ConflictPolicy 即可，创建上传时会自动调用该方法
+func (p *PanClient) ResolveConflict(familyId int64, parentId, name string, isFolder bool, policy ConflictPolicy) (*ConflictResolution, *apierror.ApiError) {
+	r := &ConflictResolution{Name: name}
+	if policy == ConflictPolicyDefault {
+		return r, nil
+	}
+
+	existed, err := p.folderFileNames(familyId, parentId)
+	if err != nil {
+		return nil, err
+	}
+	return resolveConflict(existed, name, isFolder, policy)
+}
+
+func resolveConflict(existed map[string]*AppFileEntity, name string, isFolder bool, policy ConflictPolicy) (*ConflictResolution, *apierror.ApiError) {
+	r := &ConflictResolution{Name: name}
+	fi, ok := existed[name]
+	if !ok {
+		return r, nil
+	}
+	r.Existing = fi
+	switch policy {
+	case ConflictPolicyFail:
+		return nil, apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "文件已存在："+name)
+	case ConflictPolicyOverwrite:
+		r.Overwrite = true
+	case ConflictPolicySkip:
+		r.Skip = true
+	case ConflictPolicyAutoRename:
+		r.Name = apiutil.AutoRenameFileName(name, isFolder, func(n string) bool {
+			_, e := existed[n]
+			return e
+		})
+	}
+	return r, nil
+}
+
+// resolveUploadConflict 按照上传参数中的冲突策略检测同名文件，自动重命名时修改上传的文件名
+func (p *PanClient) resolveUploadConflict(param *AppCreateUploadFileParam) (*ConflictResolution, *apierror.ApiError) {
+	if param.ConflictPolicy == ConflictPolicyDefault {
+		return nil, nil
+	}
+	r, err := p.ResolveConflict(param.FamilyId, param.ParentFolderId, param.FileName, false, param.ConflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Skip {
+		param.FileName = r.Name
+	}
+	return r, nil
+}
+
+// overwriteBackup 覆盖策略下先将目标位置的同名文件重命名为其他文件名，写入成功后再删除，写入失败则还原文件名
+type overwriteBackup struct {
+	p       *PanClient
+	drive   Drive
+	renamed map[*AppFileEntity]string
+}
+
+func (p *PanClient) newOverwriteBackup(drive Drive) *overwriteBackup {
+	return &overwriteBackup{
+		p:       p,
+		drive:   drive,
+		renamed: map[*AppFileEntity]string{},
+	}
+}
+
+// add 将同名文件重命名为文件夹中不存在的文件名，existed 会同步更新
+func (b *overwriteBackup) add(fi *AppFileEntity, existed map[string]*AppFileEntity) *apierror.ApiError {
+	oldName := fi.FileName
+	tmpName := apiutil.AutoRenameFileName(oldName, fi.IsFolder, func(n string) bool {
+		_, e := existed[n]
+		return e
+	})
+	logger.Verboseln("backup conflict file: ", fi.FileId, " ", oldName, " -> ", tmpName)
+	if _, err := b.drive.Rename(fi, tmpName); err != nil {
+		return err
+	}
+	b.renamed[fi] = oldName
+	delete(existed, oldName)
+	fi.FileName = tmpName
+	existed[tmpName] = fi
+	return nil
+}
+
+// restore 写入失败时还原同名文件的文件名
+func (b *overwriteBackup) restore() {
+	b.p.rollbackRename(b.drive, b.renamed)
+}
+
+// commit 写入成功后删除被覆盖的文件，Drive.Delete 会等待删除完成
+func (b *overwriteBackup) commit() *apierror.ApiError {
+	fileList := AppFileList{}
+	for fi := range b.renamed {
+		fileList = append(fileList, fi)
+	}
+	if len(fileList) == 0 {
+		return nil
+	}
+	if err := b.drive.Delete(fileList); err != nil {
+		return apierror.NewFailedApiError("写入成功，但是删除被覆盖的文件失败：" + err.Error())
+	}
+	return nil
+}
+
+// AppCopyFileWithPolicy 复制个人云文件到目标文件夹，按照冲突策略处理同名文件。跳过时返回已存在的文件
+// 覆盖策略会在复制成功后才删除已存在的同名文件，复制失败则保留原文件
+func (p *PanClient) AppCopyFileWithPolicy(param *AppCopyFileParam, isFolder bool, policy ConflictPolicy) (*AppFileEntity, *apierror.ApiError) {
+	if policy == ConflictPolicyDefault {
+		return p.AppCopyFile(param)
+	}
+	existed, err := p.folderFileNames(0, param.DestFolderId)
+	if err != nil {
+		return nil, err
+	}
+	r, err := resolveConflict(existed, param.DestFileName, isFolder, policy)
+	if err != nil {
+		return nil, err
+	}
+	if r.Skip {
+		return r.Existing, nil
+	}
+	backup := p.newOverwriteBackup(p.Family(0))
+	if r.Overwrite {
+		if r.Existing.FileId == param.FileId {
+			// 复制到自身所在位置，不需要覆盖
+			return r.Existing, nil
+		}
+		if err := backup.add(r.Existing, existed); err != nil {
+			return nil, err
+		}
+	}
+	fi, err := p.AppCopyFile(&AppCopyFileParam{
+		FileId:       param.FileId,
+		DestFileName: r.Name,
+		DestFolderId: param.DestFolderId,
+	})
+	if err != nil {
+		backup.restore()
+		return nil, err
+	}
+	return fi, backup.commit()
+}
+
+// CopyFileWithPolicy 复制文件到目标文件夹，按照冲突策略处理同名文件，支持个人云和家庭云
+// 家庭云复制不支持指定新文件名，自动重命名策略会先在原位置重命名文件，复制完成后还原原文件的文件名
+func (p *PanClient) CopyFileWithPolicy(familyId int64, fileList AppFileList, targetFolderId string, policy ConflictPolicy) *apierror.ApiError {
+	drive := p.Family(familyId)
+	if policy == ConflictPolicyDefault {
+		return drive.Copy(fileList, targetFolderId)
+	}
+	if familyId <= 0 {
+		for _, fi := range fileList {
+			if fi == nil {
+				continue
+			}
+			if _, err := p.AppCopyFileWithPolicy(&AppCopyFileParam{
+				FileId:       fi.FileId,
+				DestFileName: fi.FileName,
+				DestFolderId: targetFolderId,
+			}, fi.IsFolder, policy); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	existed, err := p.folderFileNames(familyId, targetFolderId)
+	if err != nil {
+		return err
+	}
+	srcExisted := map[string]map[string]*AppFileEntity{}
+	copyList := AppFileList{}
+	renamed := map[*AppFileEntity]string{}
+	backup := p.newOverwriteBackup(drive)
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		r, err := resolveConflict(existed, fi.FileName, fi.IsFolder, policy)
+		if err != nil {
+			backup.restore()
+			p.rollbackRename(drive, renamed)
+			return err
+		}
+		if r.Skip || (r.Overwrite && r.Existing.FileId == fi.FileId) {
+			continue
+		}
+		if r.Overwrite {
+			if err := backup.add(r.Existing, existed); err != nil {
+				backup.restore()
+				p.rollbackRename(drive, renamed)
+				return err
+			}
+		}
+		if r.Name != fi.FileName {
+			if err := p.renameAvoiding(familyId, drive, fi, existed, srcExisted, renamed); err != nil {
+				backup.restore()
+				p.rollbackRename(drive, renamed)
+				return err
+			}
+		}
+		existed[fi.FileName] = fi
+		copyList = append(copyList, fi)
+	}
+	if len(copyList) == 0 {
+		return nil
+	}
+	err = drive.Copy(copyList, targetFolderId)
+	// 复制完成后原文件恢复原来的文件名
+	p.rollbackRename(drive, renamed)
+	if err != nil {
+		backup.restore()
+		return err
+	}
+	return backup.commit()
+}
+
+// renameAvoiding 在原位置重命名文件，新文件名同时避开目标文件夹和原文件夹中的文件名
+func (p *PanClient) renameAvoiding(familyId int64, drive Drive, fi *AppFileEntity, existed map[string]*AppFileEntity,
+	srcExisted map[string]map[string]*AppFileEntity, renamed map[*AppFileEntity]string) *apierror.ApiError {
+	siblings, ok := srcExisted[fi.ParentId]
+	if !ok {
+		var err *apierror.ApiError
+		if siblings, err = p.folderFileNames(familyId, fi.ParentId); err != nil {
+			return err
+		}
+		srcExisted[fi.ParentId] = siblings
+	}
+	newName := apiutil.AutoRenameFileName(fi.FileName, fi.IsFolder, func(n string) bool {
+		_, e1 := existed[n]
+		_, e2 := siblings[n]
+		return e1 || e2
+	})
+	oldName := fi.FileName
+	if _, err := drive.Rename(fi, newName); err != nil {
+		return err
+	}
+	renamed[fi] = oldName
+	delete(siblings, oldName)
+	fi.FileName = newName
+	siblings[newName] = fi
+	return nil
+}
+
+// MoveFileWithPolicy 移动文件到目标文件夹，按照冲突策略处理同名文件，支持个人云和家庭云
+// 自动重命名策略会先在原位置重命名文件再移动，新文件名同时避开目标文件夹和原文件夹中的文件名，移动失败则还原文件名
+// 覆盖策略会在移动成功后才删除已存在的同名文件，移动失败则保留原文件
+func (p *PanClient) MoveFileWithPolicy(familyId int64, fileList AppFileList, targetFolderId string, policy ConflictPolicy) *apierror.ApiError {
+	drive := p.Family(familyId)
+	if policy == ConflictPolicyDefault {
+		return drive.Move(fileList, targetFolderId)
+	}
+
+	existed, err := p.folderFileNames(familyId, targetFolderId)
+	if err != nil {
+		return err
+	}
+	// 原文件夹中的文件名，按需获取
+	srcExisted := map[string]map[string]*AppFileEntity{}
+
+	moveList := AppFileList{}
+	renamed := map[*AppFileEntity]string{}
+	backup := p.newOverwriteBackup(drive)
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		if e, ok := existed[fi.FileName]; ok && e.FileId == fi.FileId {
+			// 文件已经在目标文件夹中
+			continue
+		}
+		r, err := resolveConflict(existed, fi.FileName, fi.IsFolder, policy)
+		if err != nil {
+			backup.restore()
+			p.rollbackRename(drive, renamed)
+			return err
+		}
+		if r.Skip {
+			continue
+		}
+		if r.Overwrite {
+			if err := backup.add(r.Existing, existed); err != nil {
+				backup.restore()
+				p.rollbackRename(drive, renamed)
+				return err
+			}
+		}
+		if r.Name != fi.FileName {
+			if err := p.renameAvoiding(familyId, drive, fi, existed, srcExisted, renamed); err != nil {
+				backup.restore()
+				p.rollbackRename(drive, renamed)
+				return err
+			}
+		}
+		existed[fi.FileName] = fi
+		moveList = append(moveList, fi)
+	}
+	if len(moveList) == 0 {
+		return nil
+	}
+	if err := drive.Move(moveList, targetFolderId); err != nil {
+		backup.restore()
+		p.rollbackRename(drive, renamed)
+		return err
+	}
+	return backup.commit()
+}
+
+// folderFileNames 获取文件夹下所有文件，以文件名为key
+func (p *PanClient) folderFileNames(familyId int64, folderId string) (map[string]*AppFileEntity, *apierror.ApiError) {
+	param := NewAppFileListParam()
+	param.FamilyId = familyId
+	param.FileId = folderId
+	fileResult, err := p.AppGetAllFileList(param)
+	if err != nil {
+		return nil, err
+	}
+	existed := map[string]*AppFileEntity{}
+	for _, fi := range fileResult.FileList {
+		existed[fi.FileName] = fi
+	}
+	return existed, nil
+}
+
+// rollbackRename 操作失败时还原已经重命名的文件，还原失败只记录日志
+func (p *PanClient) rollbackRename(drive Drive, renamed map[*AppFileEntity]string) {
+	for fi, oldName := range renamed {
+		if _, err := drive.Rename(fi, oldName); err != nil {
+			logger.Verboseln("rollback rename failed: ", fi.FileId, " ", fi.FileName, " -> ", oldName, " ", err.Error())
+			continue
+		}
+		fi.FileName = oldName
+	}
+}
+
+// MkdirWithPolicy 创建文件夹，按照冲突策略处理同名文件夹，支持个人云和家庭云
+// 跳过和覆盖策略都会直接返回已存在的同名文件夹，不会删除已有的文件夹
+func (p *PanClient) MkdirWithPolicy(familyId int64, parentId, dirName string, policy ConflictPolicy) (*AppMkdirResult, *apierror.ApiError) {
+	r, err := p.ResolveConflict(familyId, parentId, dirName, true, policy)
+	if err != nil {
+		return nil, err
+	}
+	if r.Existing != nil && (r.Skip || r.Overwrite) {
+		return &AppMkdirResult{
+			FileId:     r.Existing.FileId,
+			ParentId:   parentId,
+			FileName:   r.Existing.FileName,
+			LastOpTime: r.Existing.LastOpTime,
+			CreateTime: r.Existing.CreateTime,
+			Rev:        r.Existing.Rev,
+			FileCata:   r.Existing.FileCata,
+		}, nil
+	}
+	return p.Family(familyId).Mkdir(parentId, r.Name)
+}
+
+// RenameFileWithPolicy 重命名文件，按照冲突策略处理同名文件，支持个人云和家庭云。跳过时返回已存在的同名文件
+// 覆盖策略会在重命名成功后才删除已存在的同名文件
+func (p *PanClient) RenameFileWithPolicy(familyId int64, file *AppFileEntity, newName string, policy ConflictPolicy) (*AppFileEntity, *apierror.ApiError) {
+	if file == nil {
+		return nil, apierror.NewFailedApiError("请指定命名的文件")
+	}
+	drive := p.Family(familyId)
+	if policy == ConflictPolicyDefault {
+		return drive.Rename(file, newName)
+	}
+	existed, err := p.folderFileNames(familyId, file.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	r, err := resolveConflict(existed, newName, file.IsFolder, policy)
+	if err != nil {
+		return nil, err
+	}
+	if r.Skip {
+		return r.Existing, nil
+	}
+	backup := p.newOverwriteBackup(drive)
+	if r.Overwrite && r.Existing.FileId != file.FileId {
+		if err := backup.add(r.Existing, existed); err != nil {
+			return nil, err
+		}
+	}
+	fi, err := drive.Rename(file, r.Name)
+	if err != nil {
+		backup.restore()
+		return nil, err
+	}
+	return fi, backup.commit()
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// DeleteUndoEntry 被删除进入回收站的文件
+	DeleteUndoEntry struct {
+		FileId   string `json:"fileId"`
+		FileName string `json:"fileName"`
+		IsFolder bool   `json:"isFolder"`
+		// ParentPath 回收站记录的原始父文件夹路径
+		ParentPath string `json:"parentPath"`
+	}
+
+	// DeleteUndoToken 删除操作的撤销凭证，记录了删除操作产生的回收站文件，可以序列化保存
+	DeleteUndoToken struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64 `json:"familyId"`
+		// DeletedAt 删除时间，超过 DeleteUndoWindow 后不能再撤销
+		DeletedAt time.Time `json:"deletedAt"`
+		// Entries 被删除的文件
+		Entries []*DeleteUndoEntry `json:"entries"`
+	}
+)
+
+const (
+	// DeleteUndoWindow 删除后可以撤销的时长
+	DeleteUndoWindow = 24 * time.Hour
+)
+
+// Encode 将撤销凭证编码为字符串
+func (t *DeleteUndoToken) Encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Expired 撤销凭证是否已经超过可以撤销的时长
+func (t *DeleteUndoToken) Expired(now time.Time) bool {
+	return t.DeletedAt.IsZero() || now.Sub(t.DeletedAt) > DeleteUndoWindow
+}
+
+// ParseDeleteUndoToken 解析 DeleteUndoToken.Encode 编码的撤销凭证
+func ParseDeleteUndoToken(token string) (*DeleteUndoToken, *apierror.ApiError) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, apierror.NewFailedApiError("撤销凭证格式错误")
+	}
+	t := &DeleteUndoToken{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, apierror.NewFailedApiError("撤销凭证格式错误")
+	}
+	return t, nil
+}
+
+// findRecycleFiles 查找回收站中指定文件ID的记录，全部找到后不再请求后续页，返回找不到的文件ID
+func (p *PanClient) findRecycleFiles(ctx context.Context, familyId int64, fileIdList []string) (RecycleFileInfoList, []string, *apierror.ApiError) {
+	pending := map[string]bool{}
+	for _, fileId := range fileIdList {
+		pending[fileId] = true
+	}
+	found := RecycleFileInfoList{}
+	c := p.RecycleListCursor(ctx, familyId, 100)
+	for len(pending) > 0 && c.Next() {
+		fileId := strconv.FormatInt(c.Entry().FileId, 10)
+		if pending[fileId] {
+			found = append(found, c.Entry())
+			delete(pending, fileId)
+		}
+	}
+	if c.Err() != nil {
+		return nil, nil, c.Err()
+	}
+	missing := []string{}
+	for _, fileId := range fileIdList {
+		if pending[fileId] {
+			missing = append(missing, fileId)
+		}
+	}
+	return found, missing, nil
+}
+
+// DeleteWithUndo 删除文件/文件夹到回收站并等待完成，返回可以用于 Undo 的撤销凭证
+// 凭证中记录的是删除后在回收站中查找到的文件，部分文件在回收站中找不到时同时返回凭证和错误
+func (p *PanClient) DeleteWithUndo(ctx context.Context, familyId int64, fileList AppFileList) (*DeleteUndoToken, *apierror.ApiError) {
+	fileIdList := fileIdListOf(fileList)
+	if len(fileIdList) == 0 {
+		return nil, apierror.NewFailedApiError("请指定删除的文件")
+	}
+	// 家庭云的删除是异步任务，Drive.Delete 会等待任务完成后再返回
+	if err := p.Family(familyId).Delete(fileList); err != nil {
+		return nil, err
+	}
+	token := &DeleteUndoToken{
+		FamilyId:  familyId,
+		DeletedAt: time.Now(),
+		Entries:   []*DeleteUndoEntry{},
+	}
+
+	recycleList, missing, err := p.findRecycleFiles(ctx, familyId, fileIdList)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range recycleList {
+		token.Entries = append(token.Entries, &DeleteUndoEntry{
+			FileId:     strconv.FormatInt(r.FileId, 10),
+			FileName:   r.FileName,
+			IsFolder:   r.IsFolder,
+			ParentPath: r.OriginalParentPath(),
+		})
+	}
+	if len(missing) > 0 {
+		return token, apierror.NewApiError(apierror.ApiCodeFileNotFoundCode, "回收站中找不到以下删除的文件，无法撤销："+strings.Join(missing, ", "))
+	}
+	return token, nil
+}
+
+// Undo 撤销删除操作，将撤销凭证中的文件从回收站还原到原位置并等待完成，超过 DeleteUndoWindow 的凭证不能撤销
+func (p *PanClient) Undo(ctx context.Context, token *DeleteUndoToken) *apierror.ApiError {
+	if token == nil || len(token.Entries) == 0 {
+		return nil
+	}
+	if token.Expired(time.Now()) {
+		return apierror.NewFailedApiError("撤销凭证已过期，请到回收站中还原文件")
+	}
+	fileIdList := []string{}
+	for _, e := range token.Entries {
+		fileIdList = append(fileIdList, e.FileId)
+	}
+
+	restoreList, missing, err := p.findRecycleFiles(ctx, token.FamilyId, fileIdList)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		missingNames := []string{}
+		for _, e := range token.Entries {
+			for _, fileId := range missing {
+				if e.FileId == fileId {
+					missingNames = append(missingNames, e.FileName)
+				}
+			}
+		}
+		return apierror.NewApiError(apierror.ApiCodeFileNotFoundCode, "回收站中找不到以下文件，可能已被清理或者还原："+strings.Join(missingNames, ", "))
+	}
+
+	taskId, err := p.FamilyRecycleRestore(token.FamilyId, restoreList)
+	if err != nil {
+		return err
+	}
+	if taskId == "" {
+		return apierror.NewFailedApiError("创建还原任务失败")
+	}
+	opts := NewBatchTaskRunOption()
+	opts.FamilyId = token.FamilyId
+	_, err = p.WaitBatchTask(ctx, &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeRecycleRestore,
+		TaskInfos: makeBatchTaskInfoList(restoreList),
+	}, taskId, opts)
+	return err
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDeleteUndoTokenEncode(t *testing.T) {
+	token := &DeleteUndoToken{
+		FamilyId:  12345,
+		DeletedAt: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+		Entries: []*DeleteUndoEntry{
+			{FileId: "1001", FileName: "a.txt", ParentPath: "/"},
+		},
+	}
+	parsed, err := ParseDeleteUndoToken(token.Encode())
+	assert.Nil(t, err)
+	assert.Equal(t, token.FamilyId, parsed.FamilyId)
+	assert.True(t, token.DeletedAt.Equal(parsed.DeletedAt))
+	assert.Equal(t, "a.txt", parsed.Entries[0].FileName)
+
+	_, err = ParseDeleteUndoToken("not a token")
+	assert.NotNil(t, err)
+}
+
+func TestDeleteUndoTokenExpired(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.False(t, (&DeleteUndoToken{DeletedAt: now.Add(-time.Hour)}).Expired(now))
+	assert.True(t, (&DeleteUndoToken{DeletedAt: now.Add(-DeleteUndoWindow - time.Second)}).Expired(now))
+	assert.True(t, (&DeleteUndoToken{}).Expired(now))
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+)
+
+type (
+	// Drive 云盘空间统一操作接口，屏蔽个人云和家庭云的接口差异
+	Drive interface {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId() int64
+		// IsFamily 是否是家庭云
+		IsFamily() bool
+
+		// List 获取目录下的所有文件列表
+		List(folderId string) (AppFileList, *apierror.ApiError)
+		// Stat 通过文件ID获取文件详情
+		Stat(fileId string) (*AppFileEntity, *apierror.ApiError)
+		// StatPath 通过绝对路径获取文件详情
+		StatPath(pathStr string) (*AppFileEntity, *apierror.ApiError)
+		// Mkdir 在指定目录下创建文件夹
+		Mkdir(parentId, dirName string) (*AppMkdirResult, *apierror.ApiError)
+		// Rename 重命名文件/文件夹
+		Rename(file *AppFileEntity, newName string) (*AppFileEntity, *apierror.ApiError)
+		// Move 移动文件/文件夹到目标文件夹
+		Move(fileList AppFileList, targetFolderId string) *apierror.ApiError
+		// Copy 复制文件/文件夹到目标文件夹，执行完成后返回
+		Copy(fileList AppFileList, targetFolderId string) *apierror.ApiError
+		// Delete 删除文件/文件夹，删除的文件会进入回收站，执行完成后返回
+		Delete(fileList AppFileList) *apierror.ApiError
+
+		// CreateUploadFile 创建上传文件
+		CreateUploadFile(param *AppCreateUploadFileParam) (*AppCreateUploadFileResult, *apierror.ApiError)
+		// UploadFileData 上传文件数据
+		UploadFileData(uploadUrl, uploadFileId, xRequestId string, fileRange *AppFileUploadRange, uploadFunc UploadFunc) *apierror.ApiError
+		// UploadFileCommit 上传文件完成提交
+		UploadFileCommit(uploadCommitUrl, uploadFileId, xRequestId string) (*AppUploadFileCommitResult, *apierror.ApiError)
+
+		// GetFileDownloadUrl 获取文件下载链接
+		GetFileDownloadUrl(fileId string) (string, *apierror.ApiError)
+		// DownloadFileData 下载文件数据
+		DownloadFileData(downloadFileUrl string, fileRange AppFileDownloadRange, downloadFunc DownloadFuncCallback) *apierror.ApiError
+
+		// Share 创建私密分享
+		Share(fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError)
+	}
+
+	personalDrive struct {
+		p *PanClient
+	}
+
+	familyDrive struct {
+		p        *PanClient
+		familyId int64
+	}
+)
+
+// Personal 获取个人云的操作接口
+func (p *PanClient) Personal() Drive {
+	return &personalDrive{p: p}
+}
+
+// Family 获取家庭云的操作接口，familyId为0则返回个人云
+func (p *PanClient) Family(familyId int64) Drive {
+	if familyId <= 0 {
+		return p.Personal()
+	}
+	return &familyDrive{p: p, familyId: familyId}
+}
+
+func fileIdListOf(fileList AppFileList) []string {
+	fileIdList := []string{}
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		fileIdList = append(fileIdList, fi.FileId)
+	}
+	return fileIdList
+}
+
+func batchTaskInfoListOf(fileList AppFileList) BatchTaskInfoList {
+	infoList := BatchTaskInfoList{}
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		infoList = append(infoList, &BatchTaskInfo{
+			FileId:      fi.FileId,
+			FileName:    fi.FileName,
+			IsFolder:    BoolToNumber(fi.IsFolder),
+			SrcParentId: fi.ParentId,
+		})
+	}
+	return infoList
+}
+
+// 个人云
+
+func (d *personalDrive) FamilyId() int64 {
+	return 0
+}
+
+func (d *personalDrive) IsFamily() bool {
+	return false
+}
+
+func (d *personalDrive) List(folderId string) (AppFileList, *apierror.ApiError) {
+	param := NewAppFileListParam()
+	if folderId != "" {
+		param.FileId = folderId
+	}
+	r, err := d.p.AppGetAllFileList(param)
+	if err != nil {
+		return nil, err
+	}
+	return r.FileList, nil
+}
+
+func (d *personalDrive) Stat(fileId string) (*AppFileEntity, *apierror.ApiError) {
+	return d.p.AppFileInfoById(0, fileId)
+}
+
+func (d *personalDrive) StatPath(pathStr string) (*AppFileEntity, *apierror.ApiError) {
+	return d.p.AppFileInfoByPath(0, pathStr)
+}
+
+func (d *personalDrive) Mkdir(parentId, dirName string) (*AppMkdirResult, *apierror.ApiError) {
+	if parentId == "" {
+		parentId = NewAppFileEntityForRootDir().FileId
+	}
+	return d.p.AppMkdir(0, parentId, dirName)
+}
+
+func (d *personalDrive) Rename(file *AppFileEntity, newName string) (*AppFileEntity, *apierror.ApiError) {
+	if file == nil {
+		return nil, apierror.NewFailedApiError("请指定命名的文件")
+	}
+	return d.p.appRenameFileInternal(file.FileId, newName, file.IsFolder)
+}
+
+func (d *personalDrive) Move(fileList AppFileList, targetFolderId string) *apierror.ApiError {
+	fileIdList := fileIdListOf(fileList)
+	if len(fileIdList) == 0 {
+		return nil
+	}
+	_, err := d.p.AppMoveFile(fileIdList, targetFolderId)
+	return err
+}
+
+func (d *personalDrive) Copy(fileList AppFileList, targetFolderId string) *apierror.ApiError {
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		_, err := d.p.AppCopyFile(&AppCopyFileParam{
+			FileId:       fi.FileId,
+			DestFileName: fi.FileName,
+			DestFolderId: targetFolderId,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *personalDrive) Delete(fileList AppFileList) *apierror.ApiError {
+	fileIdList := fileIdListOf(fileList)
+	if len(fileIdList) == 0 {
+		return nil
+	}
+	_, err := d.p.AppDeleteFile(fileIdList)
+	return err
+}
+
+func (d *personalDrive) CreateUploadFile(param *AppCreateUploadFileParam) (*AppCreateUploadFileResult, *apierror.ApiError) {
+	param.FamilyId = 0
+	return d.p.AppCreateUploadFile(param)
+}
+
+func (d *personalDrive) UploadFileData(uploadUrl, uploadFileId, xRequestId string, fileRange *AppFileUploadRange, uploadFunc UploadFunc) *apierror.ApiError {
+	return d.p.AppUploadFileData(uploadUrl, uploadFileId, xRequestId, fileRange, uploadFunc)
+}
+
+func (d *personalDrive) UploadFileCommit(uploadCommitUrl, uploadFileId, xRequestId string) (*AppUploadFileCommitResult, *apierror.ApiError) {
+	return d.p.AppUploadFileCommit(uploadCommitUrl, uploadFileId, xRequestId)
+}
+
+func (d *personalDrive) GetFileDownloadUrl(fileId string) (string, *apierror.ApiError) {
+	return d.p.AppGetFileDownloadUrl(fileId)
+}
+
+func (d *personalDrive) DownloadFileData(downloadFileUrl string, fileRange AppFileDownloadRange, downloadFunc DownloadFuncCallback) *apierror.ApiError {
+	return d.p.AppDownloadFileData(downloadFileUrl, fileRange, downloadFunc)
+}
+
+func (d *personalDrive) Share(fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError) {
+	return d.p.SharePrivate(fileId, expiredTime)
+}
+
+// 家庭云
+
+func (d *familyDrive) FamilyId() int64 {
+	return d.familyId
+}
+
+func (d *familyDrive) IsFamily() bool {
+	return true
+}
+
+// familyFolderId 家庭云根目录ID为空字符串
+func (d *familyDrive) familyFolderId(folderId string) string {
+	if folderId == NewAppFileEntityForRootDir().FileId {
+		return ""
+	}
+	return folderId
+}
+
+func (d *familyDrive) List(folderId string) (AppFileList, *apierror.ApiError) {
+	param := NewAppFileListParam()
+	param.FamilyId = d.familyId
+	param.FileId = d.familyFolderId(folderId)
+	r, err := d.p.AppGetAllFileList(param)
+	if err != nil {
+		return nil, err
+	}
+	return r.FileList, nil
+}
+
+func (d *familyDrive) Stat(fileId string) (*AppFileEntity, *apierror.ApiError) {
+	return d.p.AppFileInfoById(d.familyId, fileId)
+}
+
+func (d *familyDrive) StatPath(pathStr string) (*AppFileEntity, *apierror.ApiError) {
+	return d.p.AppFileInfoByPath(d.familyId, pathStr)
+}
+
+func (d *familyDrive) Mkdir(parentId, dirName string) (*AppMkdirResult, *apierror.ApiError) {
+	return d.p.AppMkdir(d.familyId, d.familyFolderId(parentId), dirName)
+}
+
+func (d *familyDrive) Rename(file *AppFileEntity, newName string) (*AppFileEntity, *apierror.ApiError) {
+	if file == nil {
+		return nil, apierror.NewFailedApiError("请指定命名的文件")
+	}
+	return d.p.AppFamilyRenameFile(d.familyId, file.FileId, newName)
+}
+
+func (d *familyDrive) Move(fileList AppFileList, targetFolderId string) *apierror.ApiError {
+	for _, fi := range fileList {
+		if fi == nil {
+			continue
+		}
+		if _, err := d.p.AppFamilyMoveFile(d.familyId, fi.FileId, d.familyFolderId(targetFolderId)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *familyDrive) Copy(fileList AppFileList, targetFolderId string) *apierror.ApiError {
+	infoList := batchTaskInfoListOf(fileList)
+	if len(infoList) == 0 {
+		return nil
+	}
+	taskId, err := d.p.AppFamilyCopyFile(d.familyId, infoList, targetFolderId)
+	if err != nil {
+		return err
+	}
+	return d.waitTask(taskId, &BatchTaskParam{
+		TypeFlag:       BatchTaskTypeCopy,
+		TaskInfos:      infoList,
+		TargetFolderId: d.familyFolderId(targetFolderId),
+	})
+}
+
+func (d *familyDrive) Delete(fileList AppFileList) *apierror.ApiError {
+	infoList := batchTaskInfoListOf(fileList)
+	if len(infoList) == 0 {
+		return nil
+	}
+	taskId, err := d.p.AppFamilyDeleteFile(d.familyId, infoList)
+	if err != nil {
+		return err
+	}
+	return d.waitTask(taskId, &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeDelete,
+		TaskInfos: infoList,
+	})
+}
+
+// waitTask 家庭云的复制、删除是异步的批量任务，等待任务完成以保持和个人云一致的同步语义
+func (d *familyDrive) waitTask(taskId string, param *BatchTaskParam) *apierror.ApiError {
+	opts := NewBatchTaskRunOption()
+	opts.FamilyId = d.familyId
+	_, err := d.p.WaitBatchTask(context.Background(), param, taskId, opts)
+	return err
+}
+
+func (d *familyDrive) CreateUploadFile(param *AppCreateUploadFileParam) (*AppCreateUploadFileResult, *apierror.ApiError) {
+	param.FamilyId = d.familyId
+	return d.p.AppFamilyCreateUploadFile(param)
+}
+
+func (d *familyDrive) UploadFileData(uploadUrl, uploadFileId, xRequestId string, fileRange *AppFileUploadRange, uploadFunc UploadFunc) *apierror.ApiError {
+	return d.p.AppFamilyUploadFileData(d.familyId, uploadUrl, uploadFileId, xRequestId, fileRange, uploadFunc)
+}
+
+func (d *familyDrive) UploadFileCommit(uploadCommitUrl, uploadFileId, xRequestId string) (*AppUploadFileCommitResult, *apierror.ApiError) {
+	return d.p.AppFamilyUploadFileCommit(d.familyId, uploadCommitUrl, uploadFileId, xRequestId)
+}
+
+func (d *familyDrive) GetFileDownloadUrl(fileId string) (string, *apierror.ApiError) {
+	return d.p.AppFamilyGetFileDownloadUrl(d.familyId, fileId)
+}
+
+func (d *familyDrive) DownloadFileData(downloadFileUrl string, fileRange AppFileDownloadRange, downloadFunc DownloadFuncCallback) *apierror.ApiError {
+	return d.p.AppFamilyDownloadFileData(downloadFileUrl, fileRange, downloadFunc)
+}
+
+func (d *familyDrive) Share(fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError) {
+	return d.p.FamilySharePrivate(d.familyId, fileId, expiredTime)
+}
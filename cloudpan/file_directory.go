@@ -164,6 +164,8 @@ const (
 	MediaTypeDefault MediaType = 0
 	// MediaTypeMusic 音乐
 	MediaTypeMusic MediaType = 1
+	// MediaTypePicture 图片
+	MediaTypePicture MediaType = 2
 	// MediaTypeVideo 视频
 	MediaTypeVideo MediaType = 3
 	// MediaTypeDocument 文档
@@ -184,6 +186,23 @@ const (
 
 
 
+func (m MediaType) String() string {
+	switch m {
+	case MediaTypeDefault:
+		return "全部"
+	case MediaTypeMusic:
+		return "音乐"
+	case MediaTypePicture:
+		return "图片"
+	case MediaTypeVideo:
+		return "视频"
+	case MediaTypeDocument:
+		return "文档"
+	default:
+		return "其他"
+	}
+}
+
 // TotalSize 获取目录下文件的总大小
 func (fl FileList) TotalSize() int64 {
 	var size int64
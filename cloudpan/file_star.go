@@ -0,0 +1,101 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"strings"
+)
+
+// StarFile 将文件/文件夹标记为星标
+func (p *PanClient) StarFile(fileIdList []string) *apierror.ApiError {
+	return p.setFileStar(fileIdList, true)
+}
+
+// UnstarFile 取消文件/文件夹的星标
+func (p *PanClient) UnstarFile(fileIdList []string) *apierror.ApiError {
+	return p.setFileStar(fileIdList, false)
+}
+
+func (p *PanClient) setFileStar(fileIdList []string, star bool) *apierror.ApiError {
+	if len(fileIdList) == 0 {
+		return nil
+	}
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/v2/setFileStar.action?fileIdList=%s&star=%t",
+		WEB_URL, url.QueryEscape(strings.Join(fileIdList, ",")), star)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	body, err := p.client.DoGet(fullUrl.String())
+	if err != nil {
+		logger.Verboseln("SetFileStar failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	item := &apierror.SuccessResp{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("SetFileStar response failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	if !item.Success {
+		return apierror.NewFailedApiError("设置星标失败")
+	}
+	return nil
+}
+
+// ListStarred 获取星标文件列表，pageNum从1开始
+func (p *PanClient) ListStarred(pageNum, pageSize uint) (*FileSearchResult, *apierror.ApiError) {
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 60
+	}
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/v2/listStarFiles.action?orderBy=%d&order=%s&pageNum=%d&pageSize=%d",
+		WEB_URL, OrderByTime, OrderDesc, pageNum, pageSize)
+	logger.Verboseln("do request url: " + fullUrl.String())
+	body, err := p.client.DoGet(fullUrl.String())
+	if err != nil {
+		logger.Verboseln("ListStarred failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	item := &FileSearchResult{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("ListStarred response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	for _, f := range item.Data {
+		f.IsStarred = true
+	}
+	return item, nil
+}
+
+// ListStarredCursor 创建星标文件列表游标
+func (p *PanClient) ListStarredCursor(ctx context.Context, pageSize uint) *FileCursor {
+	if pageSize <= 0 {
+		pageSize = 60
+	}
+	return &FileCursor{c: newListCursor(ctx, 1, func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		r, err := p.ListStarred(uint(pageNum), pageSize)
+		if err != nil {
+			return nil, false, err
+		}
+		return fileListItems(r), hasMorePage(pageNum, int(pageSize), int(r.RecordCount)), nil
+	})}
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+)
+
+type (
+	// listPageFunc 获取指定页的数据，返回当前页数据和是否还有下一页
+	listPageFunc func(pageNum int) (items []interface{}, hasMore bool, err *apierror.ApiError)
+
+	// listCursor 分页列表游标，按需逐页获取数据
+	listCursor struct {
+		ctx      context.Context
+		fetch    listPageFunc
+		pageNum  int
+		items    []interface{}
+		index    int
+		hasMore  bool
+		current  interface{}
+		err      *apierror.ApiError
+		finished bool
+	}
+
+	// AppFileCursor 文件列表游标，适用于 AppFileList
+	AppFileCursor struct {
+		c *listCursor
+	}
+
+	// FileCursor 文件列表游标，适用于 FileList 和 FileSearch
+	FileCursor struct {
+		c *listCursor
+	}
+
+	// ShareCursor 分享列表游标，适用于 ShareList
+	ShareCursor struct {
+		c *listCursor
+	}
+
+	// RecycleCursor 回收站文件列表游标，适用于 RecycleList
+	RecycleCursor struct {
+		c *listCursor
+	}
+)
+
+func newListCursor(ctx context.Context, startPage int, fetch listPageFunc) *listCursor {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if startPage <= 0 {
+		startPage = 1
+	}
+	return &listCursor{
+		ctx:     ctx,
+		fetch:   fetch,
+		pageNum: startPage,
+		hasMore: true,
+	}
+}
+
+// newErrorListCursor 创建参数错误的游标，第一次调用 Next 就结束并返回错误，不发起任何请求
+func newErrorListCursor(ctx context.Context, err *apierror.ApiError) *listCursor {
+	return newListCursor(ctx, 1, func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		return nil, false, err
+	})
+}
+
+// next 移动到下一个数据项，当前页数据读取完毕才会请求下一页
+func (c *listCursor) next() bool {
+	if c.finished {
+		return false
+	}
+	for c.index >= len(c.items) {
+		if !c.hasMore {
+			c.finish()
+			return false
+		}
+		if err := c.ctx.Err(); err != nil {
+			c.err = apierror.NewFailedApiError(err.Error())
+			c.finish()
+			return false
+		}
+		items, hasMore, err := c.fetch(c.pageNum)
+		if err != nil {
+			c.err = err
+			c.finish()
+			return false
+		}
+		c.pageNum++
+		c.items = items
+		c.index = 0
+		// 空页说明已经没有数据了，避免无限请求
+		c.hasMore = hasMore && len(items) > 0
+	}
+	c.current = c.items[c.index]
+	c.index++
+	return true
+}
+
+func (c *listCursor) finish() {
+	c.finished = true
+	c.current = nil
+	c.items = nil
+}
+
+// hasMorePage 根据总数量判断是否还有下一页
+func hasMorePage(pageNum, pageSize, total int) bool {
+	return pageSize > 0 && pageNum*pageSize < total
+}
+
+// AppFileListCursor 创建文件列表游标，从 param.PageNum 开始逐页获取
+func (p *PanClient) AppFileListCursor(ctx context.Context, param *AppFileListParam) *AppFileCursor {
+	pageParam := *param
+	if pageParam.PageSize <= 0 {
+		pageParam.PageSize = 200
+	}
+	return &AppFileCursor{c: newListCursor(ctx, int(pageParam.PageNum), func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		pageParam.PageNum = uint(pageNum)
+		r, err := p.AppFileList(&pageParam)
+		if err != nil {
+			return nil, false, err
+		}
+		items := make([]interface{}, 0, len(r.FileList))
+		for _, f := range r.FileList {
+			if !param.Recursive {
+				f.ParentId = param.FileId
+			}
+			items = append(items, f)
+		}
+		return items, hasMorePage(pageNum, int(pageParam.PageSize), r.Count), nil
+	})}
+}
+
+// Next 移动到下一个文件，没有更多文件或者出错返回false
+func (c *AppFileCursor) Next() bool {
+	return c.c.next()
+}
+
+// Entry 当前文件
+func (c *AppFileCursor) Entry() *AppFileEntity {
+	if c.c.current == nil {
+		return nil
+	}
+	return c.c.current.(*AppFileEntity)
+}
+
+// Err 获取数据过程中发生的错误
+func (c *AppFileCursor) Err() *apierror.ApiError {
+	return c.c.err
+}
+
+// FileListCursor 创建文件列表游标，从 param.PageNum 开始逐页获取
+func (p *PanClient) FileListCursor(ctx context.Context, param *FileListParam) *FileCursor {
+	pageParam := *param
+	if pageParam.PageSize <= 0 {
+		pageParam.PageSize = 60
+	}
+	return &FileCursor{c: newListCursor(ctx, int(pageParam.PageNum), func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		pageParam.PageNum = uint(pageNum)
+		r, err := p.FileList(&pageParam)
+		if err != nil {
+			return nil, false, err
+		}
+		return fileListItems(r), hasMorePage(pageNum, int(pageParam.PageSize), int(r.RecordCount)), nil
+	})}
+}
+
+// FileSearchCursor 创建文件搜索结果游标，从 param.PageNum 开始逐页获取
+func (p *PanClient) FileSearchCursor(ctx context.Context, param *FileSearchParam) *FileCursor {
+	pageParam := *param
+	if pageParam.PageSize <= 0 {
+		pageParam.PageSize = 60
+	}
+	return &FileCursor{c: newListCursor(ctx, int(pageParam.PageNum), func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		pageParam.PageNum = uint(pageNum)
+		r, err := p.FileSearch(&pageParam)
+		if err != nil {
+			return nil, false, err
+		}
+		return fileListItems(r), hasMorePage(pageNum, int(pageParam.PageSize), int(r.RecordCount)), nil
+	})}
+}
+
+func fileListItems(r *FileSearchResult) []interface{} {
+	items := make([]interface{}, 0, len(r.Data))
+	for _, f := range r.Data {
+		items = append(items, f)
+	}
+	return items
+}
+
+// Next 移动到下一个文件，没有更多文件或者出错返回false
+func (c *FileCursor) Next() bool {
+	return c.c.next()
+}
+
+// Entry 当前文件
+func (c *FileCursor) Entry() *FileEntity {
+	if c.c.current == nil {
+		return nil
+	}
+	return c.c.current.(*FileEntity)
+}
+
+// Err 获取数据过程中发生的错误
+func (c *FileCursor) Err() *apierror.ApiError {
+	return c.c.err
+}
+
+// ShareListCursor 创建分享列表游标，从 param.PageNum 开始逐页获取
+func (p *PanClient) ShareListCursor(ctx context.Context, param *ShareListParam) *ShareCursor {
+	pageParam := *param
+	if pageParam.PageSize <= 0 {
+		pageParam.PageSize = 60
+	}
+	return &ShareCursor{c: newListCursor(ctx, pageParam.PageNum, func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		pageParam.PageNum = pageNum
+		r, err := p.ShareList(&pageParam)
+		if err != nil {
+			return nil, false, err
+		}
+		items := make([]interface{}, 0, len(r.Data))
+		for _, s := range r.Data {
+			items = append(items, s)
+		}
+		return items, hasMorePage(pageNum, pageParam.PageSize, r.RecordCount), nil
+	})}
+}
+
+// Next 移动到下一个分享，没有更多分享或者出错返回false
+func (c *ShareCursor) Next() bool {
+	return c.c.next()
+}
+
+// Entry 当前分享
+func (c *ShareCursor) Entry() *ShareItem {
+	if c.c.current == nil {
+		return nil
+	}
+	return c.c.current.(*ShareItem)
+}
+
+// Err 获取数据过程中发生的错误
+func (c *ShareCursor) Err() *apierror.ApiError {
+	return c.c.err
+}
+
+// RecycleListCursor 创建回收站文件列表游标，familyId为0则列出个人云回收站
+func (p *PanClient) RecycleListCursor(ctx context.Context, familyId int64, pageSize int) *RecycleCursor {
+	if pageSize <= 0 {
+		pageSize = 60
+	}
+	return &RecycleCursor{c: newListCursor(ctx, 1, func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		r, err := p.FamilyRecycleList(familyId, pageNum, pageSize)
+		if err != nil {
+			return nil, false, err
+		}
+		items := make([]interface{}, 0, len(r.FileList))
+		for _, f := range r.FileList {
+			items = append(items, f)
+		}
+		return items, hasMorePage(pageNum, pageSize, int(r.Count)), nil
+	})}
+}
+
+// Next 移动到下一个文件，没有更多文件或者出错返回false
+func (c *RecycleCursor) Next() bool {
+	return c.c.next()
+}
+
+// Entry 当前文件
+func (c *RecycleCursor) Entry() *RecycleFileInfo {
+	if c.c.current == nil {
+		return nil
+	}
+	return c.c.current.(*RecycleFileInfo)
+}
+
+// Err 获取数据过程中发生的错误
+func (c *RecycleCursor) Err() *apierror.ApiError {
+	return c.c.err
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"testing"
+)
+
+func TestListCursor(t *testing.T) {
+	fetched := []int{}
+	fetch := func(pageNum int) ([]interface{}, bool, *apierror.ApiError) {
+		fetched = append(fetched, pageNum)
+		items := []interface{}{pageNum*10 + 1, pageNum*10 + 2}
+		return items, hasMorePage(pageNum, 2, 5), nil
+	}
+
+	c := newListCursor(context.Background(), 1, fetch)
+	values := []int{}
+	for c.next() {
+		values = append(values, c.current.(int))
+	}
+	assert.Nil(t, c.err)
+	assert.Equal(t, []int{11, 12, 21, 22, 31, 32}, values)
+	assert.Equal(t, []int{1, 2, 3}, fetched)
+
+	// 提前结束不会请求后续页
+	fetched = []int{}
+	c = newListCursor(context.Background(), 1, fetch)
+	assert.True(t, c.next())
+	assert.True(t, c.next())
+	assert.Equal(t, []int{1}, fetched)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c = newListCursor(ctx, 1, fetch)
+	assert.False(t, c.next())
+	assert.NotNil(t, c.err)
+}
+
+func TestAppMediaListCursorDefaultType(t *testing.T) {
+	c := (&PanClient{}).AppMediaListCursor(context.Background(), &AppMediaListParam{MediaType: MediaTypeDefault})
+	assert.False(t, c.Next())
+	assert.NotNil(t, c.Err())
+}
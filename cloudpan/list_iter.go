@@ -0,0 +1,86 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"iter"
+)
+
+// entryCursor 各种列表游标的公共方法
+type entryCursor[T any] interface {
+	Next() bool
+	Entry() T
+	Err() *apierror.ApiError
+}
+
+// cursorSeq 将游标转换成迭代器，每次迭代都会创建新的游标从头获取
+func cursorSeq[T any, C entryCursor[T]](newCursor func() C) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		c := newCursor()
+		for c.Next() {
+			if !yield(c.Entry(), nil) {
+				return
+			}
+		}
+		if c.Err() != nil {
+			var zero T
+			yield(zero, c.Err())
+		}
+	}
+}
+
+// AppFileListSeq 返回逐页获取文件列表的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) AppFileListSeq(ctx context.Context, param *AppFileListParam) iter.Seq2[*AppFileEntity, error] {
+	return cursorSeq[*AppFileEntity](func() *AppFileCursor { return p.AppFileListCursor(ctx, param) })
+}
+
+// AppMediaListSeq 返回逐页获取媒体分类文件列表的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) AppMediaListSeq(ctx context.Context, param *AppMediaListParam) iter.Seq2[*AppFileEntity, error] {
+	return cursorSeq[*AppFileEntity](func() *AppFileCursor { return p.AppMediaListCursor(ctx, param) })
+}
+
+// AppFileSearchSeq 返回逐页获取文件搜索结果的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) AppFileSearchSeq(ctx context.Context, param *AppFileSearchParam) iter.Seq2[*AppFileEntity, error] {
+	return cursorSeq[*AppFileEntity](func() *AppFileCursor { return p.AppFileSearchCursor(ctx, param) })
+}
+
+// FileListSeq 返回逐页获取文件列表的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) FileListSeq(ctx context.Context, param *FileListParam) iter.Seq2[*FileEntity, error] {
+	return cursorSeq[*FileEntity](func() *FileCursor { return p.FileListCursor(ctx, param) })
+}
+
+// FileSearchSeq 返回逐页获取文件搜索结果的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) FileSearchSeq(ctx context.Context, param *FileSearchParam) iter.Seq2[*FileEntity, error] {
+	return cursorSeq[*FileEntity](func() *FileCursor { return p.FileSearchCursor(ctx, param) })
+}
+
+// ListStarredSeq 返回逐页获取星标文件列表的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) ListStarredSeq(ctx context.Context, pageSize uint) iter.Seq2[*FileEntity, error] {
+	return cursorSeq[*FileEntity](func() *FileCursor { return p.ListStarredCursor(ctx, pageSize) })
+}
+
+// ShareListSeq 返回逐页获取分享列表的迭代器，迭代提前结束则不再请求后续页
+func (p *PanClient) ShareListSeq(ctx context.Context, param *ShareListParam) iter.Seq2[*ShareItem, error] {
+	return cursorSeq[*ShareItem](func() *ShareCursor { return p.ShareListCursor(ctx, param) })
+}
+
+// RecycleListSeq 返回逐页获取回收站文件列表的迭代器，familyId为0则列出个人云回收站
+func (p *PanClient) RecycleListSeq(ctx context.Context, familyId int64, pageSize int) iter.Seq2[*RecycleFileInfo, error] {
+	return cursorSeq[*RecycleFileInfo](func() *RecycleCursor { return p.RecycleListCursor(ctx, familyId, pageSize) })
+}
@@ -27,11 +27,11 @@ import (
 	"image/png"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 const (
@@ -59,87 +59,85 @@ type (
 )
 
 var (
-	latestLoginParams loginParams
-	client            = requester.NewHTTPClient()
+	// pendingLoginSession 兼容 Login/LoginWithCaptcha/GetCaptchaImage 的分步调用，保存最近一次的登录会话
+	// 所有调用共用同一个会话，由 pendingLoginMutex 保护，同时登录多个账号请直接使用 LoginSession
+	pendingLoginSession *LoginSession
+	pendingLoginMutex   sync.Mutex
+	client              = requester.NewHTTPClient()
 )
 
+// Login 登录网页端，需要验证时返回对应的错误码。需要短信或者设备验证请使用 LoginSession
 func Login(username, password string) (webToken *WebLoginToken, error *apierror.ApiError) {
-	client.ResetCookiejar()
-	params, err := getLoginParams()
+	pendingLoginMutex.Lock()
+	defer pendingLoginMutex.Unlock()
+	s := NewLoginSession(username, password)
+	pendingLoginSession = s
+	step, err := s.Start()
 	if err != nil {
-		logger.Verboseln("get login params error")
 		return nil, err
 	}
-
-	err = checkNeedCaptchaCodeOrNot(username, latestLoginParams.Lt)
-	if err != nil {
-		return nil, err
-	}
-
-	// save latest params
-	latestLoginParams = params
-	token, err := LoginWithCaptcha(username, password, "")
-	if err != nil {
-		return nil, err
+	if step != LoginStepDone {
+		return nil, loginStepError(step, s.Msg)
 	}
-	return token, nil
+	return s.Token()
 }
 
 func LoginWithCaptcha(username, password, captchaCode string) (webToken *WebLoginToken, error *apierror.ApiError) {
-	//client.ResetCookiejar()
-	//latestLoginParams, _ = getLoginParams()
-
+	pendingLoginMutex.Lock()
+	defer pendingLoginMutex.Unlock()
 	webToken = &WebLoginToken{}
-	if latestLoginParams.CaptchaToken == "" {
-		latestLoginParams, _ = getLoginParams()
+	s := pendingLoginSession
+	if s != nil && s.username != "" && s.username != username {
+		// 验证码属于之前账号的登录会话，换成新的会话验证码也会失效
+		return webToken, apierror.NewFailedApiError("验证码对应的登录账号不一致，请重新登录获取验证码")
 	}
-
-	r, err := doLoginAct(username, password, captchaCode, latestLoginParams.CaptchaToken,
-		latestLoginParams.ReturnUrl, latestLoginParams.ParamId, latestLoginParams.Lt)
-	if err != nil || r.Msg != "登录成功" {
-		logger.Verboseln("login failed ", err)
-		return webToken, apierror.NewFailedApiError(err.Error())
+	if s == nil {
+		s = NewLoginSession(username, password)
+		pendingLoginSession = s
 	}
-	// request toUrl to get COOKIE_LOGIN_USER cookie
-	header := map[string]string {
-		"lt":           latestLoginParams.Lt,
-		"Content-Type": "application/x-www-form-urlencoded",
-		"Referer":      "https://open.e.189.cn/",
+	s.username = username
+	s.password = password
+	if s.params.CaptchaToken == "" {
+		params, err := s.getLoginParams()
+		if err != nil {
+			return webToken, err
+		}
+		s.params = params
 	}
-	client.Fetch("GET", r.ToUrl, nil, header)
 
-	cloudpanUrl := &url.URL{
-		Scheme: "http",
-		Host:   "cloud.189.cn",
-		Path: "/",
+	step, err := s.submit(map[string]string{"validateCode": captchaCode})
+	if err != nil {
+		return webToken, err
 	}
-	cks := client.Jar.Cookies(cloudpanUrl)
-	for _, cookie := range cks {
-		if cookie.Name == "COOKIE_LOGIN_USER" {
-			webToken.CookieLoginUser = cookie.Value
-			break
-		}
+	if step != LoginStepDone {
+		return webToken, loginStepError(step, s.Msg)
 	}
-
-	return
+	return s.Token()
 }
 
 func GetCaptchaImage() (savePath string, error *apierror.ApiError) {
-	if latestLoginParams.CaptchaToken == "" {
-		latestLoginParams, _ = getLoginParams()
+	pendingLoginMutex.Lock()
+	defer pendingLoginMutex.Unlock()
+	s := pendingLoginSession
+	if s == nil {
+		s = NewLoginSession("", "")
+		pendingLoginSession = s
+	}
+	imgContents, err := s.CaptchaImage()
+	if err != nil {
+		return "", err
 	}
 
 	removeCaptchaPath()
-	picUrl := AUTH_URL + "/picCaptcha.do?token=" + latestLoginParams.CaptchaToken
 	// save img to file
-	return saveCaptchaImg(picUrl)
+	return saveCaptchaImg(imgContents)
 }
 
-func getLoginParams() (params loginParams, error *apierror.ApiError) {
+func (s *LoginSession) getLoginParams() (params loginParams, error *apierror.ApiError) {
 	header := map[string]string {
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
-	data, err := client.Fetch("GET", WEB_URL+ "/udb/udb_login.jsp?pageId=1&redirectURL=/main.action",
+	data, err := s.client.Fetch("GET", WEB_URL+ "/udb/udb_login.jsp?pageId=1&redirectURL=/main.action",
 		nil, header)
 	if err != nil {
 		logger.Verboseln("login redirectURL occurs error: ", err.Error())
@@ -161,9 +159,9 @@ func getLoginParams() (params loginParams, error *apierror.ApiError) {
 	return
 }
 
-func checkNeedCaptchaCodeOrNot(username, lt string) (error *apierror.ApiError) {
+func (s *LoginSession) checkNeedCaptchaCodeOrNot() (error *apierror.ApiError) {
 	url := AUTH_URL + "/needcaptcha.do"
-	rsa, err := crypto.RsaEncrypt([]byte(apiutil.RsaPublicKey), []byte(username))
+	rsa, err := crypto.RsaEncrypt([]byte(apiutil.RsaPublicKey), []byte(s.username))
 	if err != nil {
 		return apierror.NewApiErrorWithError(err)
 	}
@@ -173,11 +171,11 @@ func checkNeedCaptchaCodeOrNot(username, lt string) (error *apierror.ApiError) {
 		"appKey": "cloud",
 	}
 	header := map[string]string {
-		"lt": lt,
+		"lt": s.params.Lt,
 		"Content-Type": "application/x-www-form-urlencoded",
 		"Referer": "https://open.e.189.cn/",
 	}
-	body, err := client.Fetch("POST", url, postData, header)
+	body, err := s.client.Fetch("POST", url, postData, header)
 	if err != nil {
 		logger.Verboseln("get captcha code error: ", err.Error())
 		return apierror.NewApiErrorWithError(err)
@@ -190,18 +188,22 @@ func checkNeedCaptchaCodeOrNot(username, lt string) (error *apierror.ApiError) {
 	return
 }
 
-func saveCaptchaImg(imgURL string) (savePath string, error *apierror.ApiError) {
+// fetchCaptchaImg 下载验证码图片并校验图片格式
+func (s *LoginSession) fetchCaptchaImg(imgURL string) ([]byte, *apierror.ApiError) {
 	logger.Verboseln("try to download captcha image: ", imgURL)
-	imgContents, err := client.Fetch("GET", imgURL, nil, nil)
+	imgContents, err := s.client.Fetch("GET", imgURL, nil, nil)
 	if err != nil {
-		return "", apierror.NewApiErrorWithError(fmt.Errorf("获取验证码失败, 错误: %s", err))
+		return nil, apierror.NewApiErrorWithError(fmt.Errorf("获取验证码失败, 错误: %s", err))
 	}
 
 	_, err = png.Decode(bytes.NewReader(imgContents))
 	if err != nil {
-		return "", apierror.NewApiErrorWithError(fmt.Errorf("验证码解析错误: %s", err))
+		return nil, apierror.NewApiErrorWithError(fmt.Errorf("验证码解析错误: %s", err))
 	}
+	return imgContents, nil
+}
 
+func saveCaptchaImg(imgContents []byte) (savePath string, error *apierror.ApiError) {
 	savePath = captchaPath()
 	return savePath, apierror.NewApiErrorWithError(ioutil.WriteFile(savePath, imgContents, 0777))
 }
@@ -214,28 +216,25 @@ func removeCaptchaPath() error {
 	return os.Remove(captchaPath())
 }
 
-func doLoginAct(username, password, validateCode, captchaToken, returnUrl, paramId, lt string) (result *loginResult, error *apierror.ApiError) {
+// doLoginAct 提交登录表单，extra为验证码等附加参数
+func (s *LoginSession) doLoginAct(extra map[string]string) (result *loginResult, error *apierror.ApiError) {
 	url := AUTH_URL + "/loginSubmit.do"
-	rsaUserName, _ := crypto.RsaEncrypt([]byte(apiutil.RsaPublicKey), []byte(username))
-	rsaPassword, _ := crypto.RsaEncrypt([]byte(apiutil.RsaPublicKey), []byte(password))
 	data := map[string]string {
 		"appKey": "cloud",
 		"accountType": "01",
-		"userName": "{RSA}" + apiutil.B64toHex(string(crypto.Base64Encode(rsaUserName))),
-		"password": "{RSA}" + apiutil.B64toHex(string(crypto.Base64Encode(rsaPassword))),
-		"validateCode": validateCode,
-		"captchaToken": captchaToken,
-		"returnUrl": returnUrl,
+		"userName": s.rsaField(s.username),
+		"password": s.rsaField(s.password),
+		"validateCode": "",
+		"captchaToken": s.params.CaptchaToken,
+		"returnUrl": s.params.ReturnUrl,
 		"mailSuffix": "@189.cn",
-		"paramId": paramId,
+		"paramId": s.params.ParamId,
 	}
-	header := map[string]string {
-		"lt": lt,
-		"Content-Type": "application/x-www-form-urlencoded",
-		"Referer": "https://open.e.189.cn/",
+	for k, v := range extra {
+		data[k] = v
 	}
 
-	body, err := client.Fetch("POST", url, data, header)
+	body, err := s.client.Fetch("POST", url, data, s.headers())
 	if err != nil {
 		logger.Verboseln("login with captch error ", err)
 		return nil, apierror.NewFailedApiError(err.Error())
@@ -0,0 +1,318 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/json"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"github.com/tickstep/library-go/crypto"
+	"github.com/tickstep/library-go/logger"
+	"github.com/tickstep/library-go/requester"
+	"net/url"
+)
+
+type (
+	// LoginStep 网页端登录步骤
+	LoginStep int
+
+	// LoginSession 网页端分步登录会话，遇到验证码、短信或者设备验证时停在对应步骤，提交验证码后继续登录
+	// 每个会话使用独立的cookie，可以同时进行多个账号的登录
+	LoginSession struct {
+		// Step 当前步骤
+		Step LoginStep
+		// Msg 服务器返回的最近一次提示信息
+		Msg string
+
+		username string
+		password string
+		params   loginParams
+		client   *requester.HTTPClient
+		token    *WebLoginToken
+	}
+)
+
+const (
+	// LoginStepInit 尚未开始登录
+	LoginStepInit LoginStep = 0
+	// LoginStepNeedCaptcha 需要图片验证码
+	LoginStepNeedCaptcha LoginStep = 1
+	// LoginStepNeedSmsCode 需要短信验证码，实验性支持
+	LoginStepNeedSmsCode LoginStep = 2
+	// LoginStepNeedDeviceVerify 新设备登录，需要设备验证，实验性支持
+	LoginStepNeedDeviceVerify LoginStep = 3
+	// LoginStepDone 登录成功
+	LoginStepDone LoginStep = 4
+	// LoginStepFailed 登录失败，例如密码错误
+	LoginStepFailed LoginStep = 5
+)
+
+const (
+	// 登录提交结果码，不同的结果码对应需要的验证方式，未知的结果码视为登录失败。
+	// 短信验证(-5)和设备验证(-134)的结果码没有公开文档，未经验证，属于实验性支持
+	loginResultSuccess          = 0
+	loginResultNeedCaptcha      = -2
+	loginResultNeedSmsCode      = -5
+	loginResultNeedDeviceVerify = -134
+
+	// 短信和设备验证接口没有公开文档，接口地址未经官方确认，属于实验性支持，如果服务器调整接口只需修改这里
+	loginSendSmsCodeUrl          = AUTH_URL + "/sendSmsCode.do"
+	loginSendDeviceVerifyCodeUrl = AUTH_URL + "/sendDeviceVerifyCode.do"
+	loginDeviceVerifyUrl         = AUTH_URL + "/deviceVerify.do"
+)
+
+func (s LoginStep) String() string {
+	switch s {
+	case LoginStepInit:
+		return "未开始"
+	case LoginStepNeedCaptcha:
+		return "需要图片验证码"
+	case LoginStepNeedSmsCode:
+		return "需要短信验证码"
+	case LoginStepNeedDeviceVerify:
+		return "需要设备验证"
+	case LoginStepDone:
+		return "登录成功"
+	case LoginStepFailed:
+		return "登录失败"
+	default:
+		return "未知"
+	}
+}
+
+// NeedCode 当前步骤是否需要提交验证码
+func (s LoginStep) NeedCode() bool {
+	return s == LoginStepNeedCaptcha || s == LoginStepNeedSmsCode || s == LoginStepNeedDeviceVerify
+}
+
+// NewLoginSession 创建网页端分步登录会话
+func NewLoginSession(username, password string) *LoginSession {
+	return &LoginSession{
+		Step:     LoginStepInit,
+		username: username,
+		password: password,
+		client:   requester.NewHTTPClient(),
+	}
+}
+
+// Start 开始登录，返回登录后所处的步骤。需要验证时返回对应的步骤，调用 SubmitCode 提交验证码继续登录
+func (s *LoginSession) Start() (LoginStep, *apierror.ApiError) {
+	s.client.ResetCookiejar()
+	s.token = nil
+	params, err := s.getLoginParams()
+	if err != nil {
+		logger.Verboseln("get login params error")
+		return s.fail(err)
+	}
+	s.params = params
+
+	err = s.checkNeedCaptchaCodeOrNot()
+	if err != nil {
+		if err.Code == apierror.ApiCodeNeedCaptchaCode {
+			s.Step = LoginStepNeedCaptcha
+			s.Msg = err.Err
+			return s.Step, nil
+		}
+		return s.fail(err)
+	}
+	return s.submit(map[string]string{})
+}
+
+// CaptchaImage 获取图片验证码的PNG图片数据
+func (s *LoginSession) CaptchaImage() ([]byte, *apierror.ApiError) {
+	if s.params.CaptchaToken == "" {
+		params, err := s.getLoginParams()
+		if err != nil {
+			return nil, err
+		}
+		s.params = params
+	}
+	return s.fetchCaptchaImg(AUTH_URL + "/picCaptcha.do?token=" + s.params.CaptchaToken)
+}
+
+// SendVerifyCode 发送短信验证码，用于短信验证和设备验证步骤。接口没有公开文档，属于实验性支持
+func (s *LoginSession) SendVerifyCode() *apierror.ApiError {
+	var urlStr string
+	switch s.Step {
+	case LoginStepNeedSmsCode:
+		urlStr = loginSendSmsCodeUrl
+	case LoginStepNeedDeviceVerify:
+		urlStr = loginSendDeviceVerifyCodeUrl
+	default:
+		return apierror.NewFailedApiError("当前步骤不需要发送短信验证码：" + s.Step.String())
+	}
+	data := map[string]string{
+		"appKey":      "cloud",
+		"accountType": "01",
+		"userName":    s.rsaField(s.username),
+		"paramId":     s.params.ParamId,
+	}
+	body, err := s.client.Fetch("POST", urlStr, data, s.headers())
+	if err != nil {
+		logger.Verboseln("send verify code error ", err)
+		return apierror.NewApiErrorWithError(err)
+	}
+	logger.Verboseln("response: " + string(body))
+	r := &loginResult{}
+	if err := json.Unmarshal(body, r); err != nil {
+		logger.Verboseln("parse send verify code json error ", err)
+		return apierror.NewFailedApiError(err.Error())
+	}
+	if r.Result != 0 {
+		return apierror.NewFailedApiError("发送验证码失败：" + r.Msg)
+	}
+	return nil
+}
+
+// SubmitCode 提交当前步骤需要的验证码，返回提交后所处的步骤。短信验证和设备验证属于实验性支持
+func (s *LoginSession) SubmitCode(code string) (LoginStep, *apierror.ApiError) {
+	switch s.Step {
+	case LoginStepNeedCaptcha:
+		return s.submit(map[string]string{"validateCode": code})
+	case LoginStepNeedSmsCode:
+		return s.submit(map[string]string{"dynamicCheck": "TRUE", "smsValidateCode": code})
+	case LoginStepNeedDeviceVerify:
+		return s.deviceVerify(code)
+	}
+	return s.Step, apierror.NewFailedApiError("当前步骤不需要验证码：" + s.Step.String())
+}
+
+// Token 登录成功后获取登录凭证
+func (s *LoginSession) Token() (*WebLoginToken, *apierror.ApiError) {
+	if s.Step != LoginStepDone || s.token == nil {
+		return nil, loginStepError(s.Step, s.Msg)
+	}
+	return s.token, nil
+}
+
+// loginStepError 将未完成的登录步骤转换成错误
+func loginStepError(step LoginStep, msg string) *apierror.ApiError {
+	switch step {
+	case LoginStepDone:
+		return nil
+	case LoginStepNeedCaptcha:
+		return apierror.NewApiError(apierror.ApiCodeNeedCaptchaCode, "需要验证码")
+	case LoginStepNeedSmsCode:
+		return apierror.NewApiError(apierror.ApiCodeNeedSmsCode, "需要短信验证码")
+	case LoginStepNeedDeviceVerify:
+		return apierror.NewApiError(apierror.ApiCodeNeedDeviceVerify, "需要设备验证")
+	}
+	if msg == "" {
+		msg = "登录失败"
+	}
+	return apierror.NewFailedApiError(msg)
+}
+
+// loginStepOf 根据登录提交的结果码判断下一步
+func loginStepOf(r *loginResult) LoginStep {
+	switch r.Result {
+	case loginResultSuccess:
+		if r.ToUrl != "" {
+			return LoginStepDone
+		}
+	case loginResultNeedCaptcha:
+		return LoginStepNeedCaptcha
+	case loginResultNeedSmsCode:
+		return LoginStepNeedSmsCode
+	case loginResultNeedDeviceVerify:
+		return LoginStepNeedDeviceVerify
+	}
+	return LoginStepFailed
+}
+
+func (s *LoginSession) fail(err *apierror.ApiError) (LoginStep, *apierror.ApiError) {
+	s.Step = LoginStepFailed
+	s.Msg = err.Err
+	return s.Step, err
+}
+
+// submit 提交登录表单，extra为验证码等附加参数
+func (s *LoginSession) submit(extra map[string]string) (LoginStep, *apierror.ApiError) {
+	r, err := s.doLoginAct(extra)
+	if err != nil {
+		logger.Verboseln("login failed ", err)
+		return s.fail(err)
+	}
+	return s.applyResult(r)
+}
+
+func (s *LoginSession) deviceVerify(code string) (LoginStep, *apierror.ApiError) {
+	data := map[string]string{
+		"appKey":     "cloud",
+		"userName":   s.rsaField(s.username),
+		"verifyCode": code,
+		"returnUrl":  s.params.ReturnUrl,
+		"paramId":    s.params.ParamId,
+	}
+	body, err := s.client.Fetch("POST", loginDeviceVerifyUrl, data, s.headers())
+	if err != nil {
+		logger.Verboseln("device verify error ", err)
+		return s.fail(apierror.NewFailedApiError(err.Error()))
+	}
+	logger.Verboseln("response: " + string(body))
+	r := &loginResult{}
+	if err := json.Unmarshal(body, r); err != nil {
+		logger.Verboseln("parse device verify json error ", err)
+		return s.fail(apierror.NewFailedApiError(err.Error()))
+	}
+	return s.applyResult(r)
+}
+
+// applyResult 根据提交结果更新步骤，登录成功则获取cookie
+func (s *LoginSession) applyResult(r *loginResult) (LoginStep, *apierror.ApiError) {
+	s.Msg = r.Msg
+	s.Step = loginStepOf(r)
+	switch s.Step {
+	case LoginStepFailed:
+		return s.Step, loginStepError(s.Step, r.Msg)
+	case LoginStepDone:
+		token, err := s.fetchToken(r.ToUrl)
+		if err != nil {
+			return s.fail(err)
+		}
+		s.token = token
+	}
+	return s.Step, nil
+}
+
+// fetchToken 请求toUrl获取COOKIE_LOGIN_USER
+func (s *LoginSession) fetchToken(toUrl string) (*WebLoginToken, *apierror.ApiError) {
+	s.client.Fetch("GET", toUrl, nil, s.headers())
+
+	cloudpanUrl := &url.URL{
+		Scheme: "http",
+		Host:   "cloud.189.cn",
+		Path:   "/",
+	}
+	for _, cookie := range s.client.Jar.Cookies(cloudpanUrl) {
+		if cookie.Name == "COOKIE_LOGIN_USER" {
+			return &WebLoginToken{CookieLoginUser: cookie.Value}, nil
+		}
+	}
+	return nil, apierror.NewFailedApiError("获取登录凭证失败")
+}
+
+func (s *LoginSession) headers() map[string]string {
+	return map[string]string{
+		"lt":           s.params.Lt,
+		"Content-Type": "application/x-www-form-urlencoded",
+		"Referer":      "https://open.e.189.cn/",
+	}
+}
+
+func (s *LoginSession) rsaField(value string) string {
+	rsa, _ := crypto.RsaEncrypt([]byte(apiutil.RsaPublicKey), []byte(value))
+	return "{RSA}" + apiutil.B64toHex(string(crypto.Base64Encode(rsa)))
+}
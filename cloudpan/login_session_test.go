@@ -0,0 +1,41 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"testing"
+)
+
+func TestLoginStepOf(t *testing.T) {
+	assert.Equal(t, LoginStepDone, loginStepOf(&loginResult{Result: 0, Msg: "登录成功", ToUrl: "https://cloud.189.cn/"}))
+	assert.Equal(t, LoginStepFailed, loginStepOf(&loginResult{Result: 0, Msg: "登录成功"}))
+	assert.Equal(t, LoginStepNeedCaptcha, loginStepOf(&loginResult{Result: loginResultNeedCaptcha, Msg: "图形验证码错误"}))
+	assert.Equal(t, LoginStepNeedSmsCode, loginStepOf(&loginResult{Result: loginResultNeedSmsCode, Msg: "请输入短信验证码"}))
+	assert.Equal(t, LoginStepNeedDeviceVerify, loginStepOf(&loginResult{Result: loginResultNeedDeviceVerify, Msg: "新设备登录，请进行设备验证码校验"}))
+	// 结果码决定步骤，不依赖提示信息
+	assert.Equal(t, LoginStepFailed, loginStepOf(&loginResult{Result: -1, Msg: "请输入验证码"}))
+}
+
+func TestLoginStepError(t *testing.T) {
+	assert.Nil(t, loginStepError(LoginStepDone, ""))
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeNeedCaptchaCode), loginStepError(LoginStepNeedCaptcha, "").Code)
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeNeedSmsCode), loginStepError(LoginStepNeedSmsCode, "").Code)
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeNeedDeviceVerify), loginStepError(LoginStepNeedDeviceVerify, "").Code)
+	assert.Equal(t, "密码错误", loginStepError(LoginStepFailed, "密码错误").Err)
+	assert.True(t, LoginStepNeedSmsCode.NeedCode())
+	assert.False(t, LoginStepDone.NeedCode())
+}
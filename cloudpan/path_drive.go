@@ -0,0 +1,319 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apiutil"
+	"path"
+	"strings"
+)
+
+type (
+	// PathDrive 基于路径的文件操作，自动解析路径对应的文件ID，支持个人云和家庭云
+	PathDrive struct {
+		p     *PanClient
+		drive Drive
+	}
+)
+
+// PathDrive 获取基于路径的文件操作接口，familyId为0代表个人云
+func (p *PanClient) PathDrive(familyId int64) *PathDrive {
+	return &PathDrive{
+		p:     p,
+		drive: p.Family(familyId),
+	}
+}
+
+// Drive 获取对应的ID操作接口
+func (pd *PathDrive) Drive() Drive {
+	return pd.drive
+}
+
+func cleanAbsPath(pathStr string) (string, *apierror.ApiError) {
+	if pathStr == "" {
+		pathStr = "/"
+	}
+	if !path.IsAbs(pathStr) {
+		return "", apierror.NewFailedApiError("路径必须是绝对路径：" + pathStr)
+	}
+	return path.Clean(pathStr), nil
+}
+
+// Stat 获取路径对应的文件详情
+func (pd *PathDrive) Stat(pathStr string) (*AppFileEntity, *apierror.ApiError) {
+	pathStr, err := cleanAbsPath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := pd.drive.StatPath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Path == "" {
+		fi.Path = pathStr
+	}
+	return fi, nil
+}
+
+// MkdirAll 递归创建文件夹，已存在的文件夹会直接使用，返回最后一级文件夹
+func (pd *PathDrive) MkdirAll(pathStr string) (*AppFileEntity, *apierror.ApiError) {
+	pathStr, err := cleanAbsPath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	parent := NewAppFileEntityForRootDir()
+	parent.Path = "/"
+	if pathStr == "/" {
+		return parent, nil
+	}
+
+	for _, name := range strings.Split(pathStr, PathSeparator)[1:] {
+		fileList, err := pd.drive.List(parent.FileId)
+		if err != nil {
+			return nil, err
+		}
+		var next *AppFileEntity
+		for _, fi := range fileList {
+			if fi.FileName == name {
+				if !fi.IsFolder {
+					return nil, apierror.NewFailedApiError("存在同名文件，无法创建文件夹：" + path.Join(parent.Path, name))
+				}
+				next = fi
+				break
+			}
+		}
+		if next == nil {
+			if !apiutil.CheckFileNameValid(name) {
+				return nil, apierror.NewFailedApiError("文件夹名不能包含特殊字符：" + apiutil.FileNameSpecialChars)
+			}
+			r, err := pd.drive.Mkdir(parent.FileId, name)
+			if err != nil {
+				return nil, err
+			}
+			next = &AppFileEntity{
+				FileId:     r.FileId,
+				ParentId:   parent.FileId,
+				FileName:   name,
+				IsFolder:   true,
+				LastOpTime: r.LastOpTime,
+				CreateTime: r.CreateTime,
+				Rev:        r.Rev,
+			}
+		}
+		next.ParentId = parent.FileId
+		next.Path = path.Join(parent.Path, name)
+		parent = next
+	}
+	return parent, nil
+}
+
+// Rename 重命名路径对应的文件或文件夹
+func (pd *PathDrive) Rename(pathStr, newName string) (*AppFileEntity, *apierror.ApiError) {
+	if !apiutil.CheckFileNameValid(newName) || newName == "" {
+		return nil, apierror.NewApiError(apierror.ApiCodeInvalidFileName, "文件名不能包含特殊字符："+apiutil.FileNameSpecialChars)
+	}
+	fi, err := pd.Stat(pathStr)
+	if err != nil {
+		return nil, err
+	}
+	if fi.FileId == NewAppFileEntityForRootDir().FileId {
+		return nil, apierror.NewFailedApiError("不能重命名根目录")
+	}
+	return pd.drive.Rename(fi, newName)
+}
+
+// resolveTarget 解析目标路径，返回目标文件夹和目标文件名
+// 目标路径以"/"结尾或者是已存在的文件夹，则放到该文件夹下并保持原文件名，否则作为新的完整路径
+func (pd *PathDrive) resolveTarget(src *AppFileEntity, dstPath string) (*AppFileEntity, string, *apierror.ApiError) {
+	isDir := strings.HasSuffix(dstPath, PathSeparator)
+	dstPath, err := cleanAbsPath(dstPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isDir {
+		if fi, err := pd.Stat(dstPath); err == nil {
+			if !fi.IsFolder {
+				return nil, "", apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "目标文件已存在："+dstPath)
+			}
+			isDir = true
+		} else if err.Code != apierror.ApiCodeFileNotFoundCode {
+			return nil, "", err
+		}
+	}
+
+	dirPath, name := dstPath, src.FileName
+	if !isDir {
+		dirPath, name = path.Dir(dstPath), path.Base(dstPath)
+	}
+	dir, err := pd.MkdirAll(dirPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if isDir {
+		// 放到文件夹下时检测文件夹中是否已存在同名文件，原位置本身除外
+		existed, err := pd.exists(path.Join(dir.Path, name))
+		if err != nil {
+			return nil, "", err
+		}
+		if existed != nil && existed.FileId != src.FileId {
+			return nil, "", apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "目标文件已存在："+path.Join(dir.Path, name))
+		}
+	}
+	return dir, name, nil
+}
+
+// exists 获取路径对应的文件，不存在则返回nil
+func (pd *PathDrive) exists(pathStr string) (*AppFileEntity, *apierror.ApiError) {
+	fi, err := pd.Stat(pathStr)
+	if err != nil {
+		if err.Code == apierror.ApiCodeFileNotFoundCode {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return fi, nil
+}
+
+// checkNotInSubtree 检测目标路径不在源文件夹内，避免把文件夹移动或者复制到自身的子目录中
+func checkNotInSubtree(src *AppFileEntity, dstPath string) *apierror.ApiError {
+	if !src.IsFolder {
+		return nil
+	}
+	dstPath, err := cleanAbsPath(dstPath)
+	if err != nil {
+		return err
+	}
+	if dstPath == src.Path || strings.HasPrefix(dstPath, strings.TrimSuffix(src.Path, PathSeparator)+PathSeparator) {
+		return apierror.NewFailedApiError("不能将文件夹移动或者复制到自身的子目录中：" + src.Path)
+	}
+	return nil
+}
+
+// Move 移动文件或文件夹，例如 Move("/a/b.txt", "/c/") 或者 Move("/a/b.txt", "/c/d.txt")，目标文件夹不存在会自动创建
+// 需要修改文件名时先在原位置重命名再移动，移动失败会还原文件名
+func (pd *PathDrive) Move(srcPath, dstPath string) *apierror.ApiError {
+	src, err := pd.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if src.FileId == NewAppFileEntityForRootDir().FileId {
+		return apierror.NewFailedApiError("不能移动根目录")
+	}
+	if err := checkNotInSubtree(src, dstPath); err != nil {
+		return err
+	}
+	dir, name, err := pd.resolveTarget(src, dstPath)
+	if err != nil {
+		return err
+	}
+	renamed := map[*AppFileEntity]string{}
+	if name != src.FileName {
+		if dir.FileId != src.ParentId {
+			srcDirPath := path.Join(path.Dir(src.Path), name)
+			existed, err := pd.exists(srcDirPath)
+			if err != nil {
+				return err
+			}
+			if existed != nil {
+				return apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "原文件夹中已存在同名文件，无法重命名："+srcDirPath)
+			}
+		}
+		oldName := src.FileName
+		if _, err := pd.drive.Rename(src, name); err != nil {
+			return err
+		}
+		renamed[src] = oldName
+		src.FileName = name
+	}
+	if dir.FileId == src.ParentId {
+		return nil
+	}
+	if err := pd.drive.Move(AppFileList{src}, dir.FileId); err != nil {
+		pd.p.rollbackRename(pd.drive, renamed)
+		return err
+	}
+	return nil
+}
+
+// Copy 复制文件或文件夹，目标路径规则和 Move 一致，目标文件夹不存在会自动创建
+// 家庭云复制不支持指定文件名，需要修改文件名时复制完成后再重命名
+func (pd *PathDrive) Copy(srcPath, dstPath string) *apierror.ApiError {
+	src, err := pd.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if err := checkNotInSubtree(src, dstPath); err != nil {
+		return err
+	}
+	dir, name, err := pd.resolveTarget(src, dstPath)
+	if err != nil {
+		return err
+	}
+	if name == src.FileName {
+		if dir.FileId == src.ParentId {
+			return apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "目标文件已存在："+src.Path)
+		}
+		return pd.drive.Copy(AppFileList{src}, dir.FileId)
+	}
+	if !pd.drive.IsFamily() {
+		_, err = pd.p.AppCopyFile(&AppCopyFileParam{
+			FileId:       src.FileId,
+			DestFileName: name,
+			DestFolderId: dir.FileId,
+		})
+		return err
+	}
+
+	// 家庭云先以原文件名复制，目标文件夹中不能存在原文件名的文件
+	copiedPath := path.Join(dir.Path, src.FileName)
+	existed, err := pd.exists(copiedPath)
+	if err != nil {
+		return err
+	}
+	if existed != nil {
+		return apierror.NewApiError(apierror.ApiCodeFileAlreadyExisted, "家庭云复制需要先使用原文件名，目标文件夹中已存在："+copiedPath)
+	}
+	if err := pd.drive.Copy(AppFileList{src}, dir.FileId); err != nil {
+		return err
+	}
+	copied, err := pd.Stat(copiedPath)
+	if err != nil {
+		return err
+	}
+	_, err = pd.drive.Rename(copied, name)
+	return err
+}
+
+// Remove 删除文件或文件夹，删除的文件会进入回收站。非空文件夹需要指定 recursive
+func (pd *PathDrive) Remove(pathStr string, recursive bool) *apierror.ApiError {
+	fi, err := pd.Stat(pathStr)
+	if err != nil {
+		return err
+	}
+	if fi.FileId == NewAppFileEntityForRootDir().FileId {
+		return apierror.NewFailedApiError("不能删除根目录")
+	}
+	if fi.IsFolder && !recursive {
+		fileList, err := pd.drive.List(fi.FileId)
+		if err != nil {
+			return err
+		}
+		if len(fileList) > 0 {
+			return apierror.NewFailedApiError("文件夹不为空：" + fi.Path)
+		}
+	}
+	return pd.drive.Delete(AppFileList{fi})
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCheckNotInSubtree(t *testing.T) {
+	dir := &AppFileEntity{FileId: "1", Path: "/a/dir", IsFolder: true}
+	assert.NotNil(t, checkNotInSubtree(dir, "/a/dir"))
+	assert.NotNil(t, checkNotInSubtree(dir, "/a/dir/"))
+	assert.NotNil(t, checkNotInSubtree(dir, "/a/dir/sub/x"))
+	assert.Nil(t, checkNotInSubtree(dir, "/a/dir2/"))
+	assert.Nil(t, checkNotInSubtree(dir, "/b/"))
+
+	file := &AppFileEntity{FileId: "2", Path: "/a/file.txt"}
+	assert.Nil(t, checkNotInSubtree(file, "/a/file.txt/"))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
 	"github.com/tickstep/library-go/logger"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -40,8 +41,10 @@ type (
 		Md5 string `json:"md5"`
 		// MediaType 媒体类型
 		MediaType int `json:"mediaType"`
-		// PathStr 文件的完整路径
+		// PathStr 文件删除前的路径，接口没有说明是否包含文件名本身，所在文件夹路径请使用 OriginalParentPath 获取
 		PathStr string `json:"pathStr"`
+		// IsFolder 是否是文件夹
+		IsFolder bool `json:"isFolder"`
 	}
 
 	RecycleFileInfoList []*RecycleFileInfo
@@ -62,6 +65,11 @@ type (
 
 // RecycleList 列出回收站文件列表
 func (p *PanClient) RecycleList(pageNum, pageSize int) (result *RecycleFileListResult, error *apierror.ApiError) {
+	return p.FamilyRecycleList(0, pageNum, pageSize)
+}
+
+// FamilyRecycleList 列出家庭云回收站文件列表，familyId为0则列出个人云回收站
+func (p *PanClient) FamilyRecycleList(familyId int64, pageNum, pageSize int) (result *RecycleFileListResult, error *apierror.ApiError) {
 	if pageNum <= 1 {
 		pageNum = 1
 	}
@@ -69,8 +77,13 @@ func (p *PanClient) RecycleList(pageNum, pageSize int) (result *RecycleFileListR
 		pageSize = 60
 	}
 	fullUrl := &strings.Builder{}
-	fmt.Fprintf(fullUrl, "%s/api/open/file/listRecycleBinFiles.action?pageNum=%d&pageSize=%d&iconOption=1&family=false",
-		WEB_URL, pageNum, pageSize)
+	if familyId <= 0 {
+		fmt.Fprintf(fullUrl, "%s/api/open/file/listRecycleBinFiles.action?pageNum=%d&pageSize=%d&iconOption=1&family=false",
+			WEB_URL, pageNum, pageSize)
+	} else {
+		fmt.Fprintf(fullUrl, "%s/api/open/file/listRecycleBinFiles.action?pageNum=%d&pageSize=%d&iconOption=1&family=true&familyId=%d",
+			WEB_URL, pageNum, pageSize, familyId)
+	}
 	logger.Verboseln("do request url: " + fullUrl.String())
 	headers := map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded; charset=UTF-8",
@@ -132,20 +145,34 @@ func (p *PanClient) RecycleRestore(fileList []*RecycleFileInfo) (taskId string,
 	return p.CreateBatchTask(taskReqParam)
 }
 
+// FamilyRecycleRestore 还原家庭云回收站文件或目录，返回批量任务ID
+func (p *PanClient) FamilyRecycleRestore(familyId int64, fileList []*RecycleFileInfo) (taskId string, err *apierror.ApiError) {
+	if familyId <= 0 {
+		return p.RecycleRestore(fileList)
+	}
+	if fileList == nil {
+		return "", nil
+	}
+
+	taskReqParam := &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeRecycleRestore,
+		TaskInfos: makeBatchTaskInfoList(fileList),
+	}
+	return p.AppCreateBatchTask(familyId, taskReqParam)
+}
+
 func makeBatchTaskInfoList(opFileList []*RecycleFileInfo) (infoList BatchTaskInfoList) {
-	//for _, fe := range opFileList {
-	//	isFolder := 0
-	//	if fe.IsFolder {
-	//		isFolder = 1
-	//	}
-	//	infoItem := &BatchTaskInfo{
-	//		FileId:      fe.FileId,
-	//		FileName:    fe.FileName,
-	//		IsFolder:    isFolder,
-	//		SrcParentId: fe.ParentId,
-	//	}
-	//	infoList = append(infoList, infoItem)
-	//}
+	for _, fe := range opFileList {
+		if fe == nil {
+			continue
+		}
+		infoItem := &BatchTaskInfo{
+			FileId:   strconv.FormatInt(fe.FileId, 10),
+			FileName: fe.FileName,
+			IsFolder: BoolToNumber(fe.IsFolder),
+		}
+		infoList = append(infoList, infoItem)
+	}
 	return
 }
 
@@ -175,3 +202,40 @@ func (p *PanClient) RecycleClear(familyId int64) *apierror.ApiError {
 	}
 	return nil
 }
+
+// RecycleClearFiles 创建彻底删除回收站指定文件的批量任务，返回批量任务ID
+func (p *PanClient) RecycleClearFiles(familyId int64, fileList []*RecycleFileInfo) (taskId string, err *apierror.ApiError) {
+	if fileList == nil {
+		return "", nil
+	}
+	taskReqParam := &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeClearRecycle,
+		TaskInfos: makeBatchTaskInfoList(fileList),
+	}
+	if familyId <= 0 {
+		return p.CreateBatchTask(taskReqParam)
+	}
+	return p.AppCreateBatchTask(familyId, taskReqParam)
+}
+
+// RecycleEmptyAllFamily 清空当前用户所有家庭云的回收站，返回清空失败的家庭云ID
+func (p *PanClient) RecycleEmptyAllFamily() (failedFamilyIds []int64, err *apierror.ApiError) {
+	familyList, err := p.AppFamilyGetFamilyList()
+	if err != nil {
+		return nil, err
+	}
+	failedFamilyIds = []int64{}
+	for _, family := range familyList.FamilyInfoList {
+		if family == nil {
+			continue
+		}
+		if e := p.RecycleClear(family.FamilyId); e != nil {
+			logger.Verboseln("empty family recycle bin failed: ", family.FamilyId, " ", e.Error())
+			failedFamilyIds = append(failedFamilyIds, family.FamilyId)
+		}
+	}
+	if len(failedFamilyIds) > 0 {
+		return failedFamilyIds, apierror.NewFailedApiError("部分家庭云回收站清空失败")
+	}
+	return failedFamilyIds, nil
+}
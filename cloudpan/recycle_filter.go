@@ -0,0 +1,251 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// RecycleFilter 回收站文件过滤条件，零值条件不参与过滤
+	RecycleFilter struct {
+		// PathPrefix 原始路径前缀，例如"/我的文档"
+		PathPrefix string
+		// NamePattern 文件名通配符，例如"*.jpg"
+		NamePattern string
+		// DeletedAfter 删除时间晚于该时间
+		DeletedAfter time.Time
+		// DeletedBefore 删除时间早于该时间
+		DeletedBefore time.Time
+	}
+
+	// RecycleRestoreParam 回收站还原参数
+	RecycleRestoreParam struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// FileList 需要还原的回收站文件
+		FileList RecycleFileInfoList
+		// TargetFolderId 还原到的文件夹ID，为空则还原到原始位置
+		TargetFolderId string
+		// RecreateParents 原始位置的父文件夹不存在时是否重新创建，还原后文件会移动到重新创建的文件夹中
+		RecreateParents bool
+		// Policy 还原位置存在同名文件时的处理方式
+		Policy ConflictPolicy
+	}
+)
+
+// OriginalParentPath 文件删除前所在的文件夹路径。PathStr 可能是文件的完整路径，也可能是所在文件夹的路径，
+// 最后一级和文件名相同时按文件的完整路径处理，文件夹和所在文件夹同名时无法区分，需要结合网盘中的文件判断
+func (r *RecycleFileInfo) OriginalParentPath() string {
+	if r.PathStr == "" {
+		return "/"
+	}
+	p := path.Clean("/" + r.PathStr)
+	if p != "/" && path.Base(p) == r.FileName {
+		return path.Dir(p)
+	}
+	return p
+}
+
+// recycleParentPath 获取回收站文件删除前所在的文件夹路径，PathStr 最后一级和文件名相同时，
+// 如果该路径在网盘中是文件夹，说明 PathStr 是所在文件夹的路径（文件已在回收站，不会占用原来的路径）
+func (pd *PathDrive) recycleParentPath(r *RecycleFileInfo) (string, *apierror.ApiError) {
+	parentPath := r.OriginalParentPath()
+	if r.PathStr == "" {
+		return parentPath, nil
+	}
+	p := path.Clean("/" + r.PathStr)
+	if p == parentPath {
+		return parentPath, nil
+	}
+	fi, err := pd.exists(p)
+	if err != nil {
+		return "", err
+	}
+	if fi != nil && fi.IsFolder && fi.FileId != strconv.FormatInt(r.FileId, 10) {
+		return p, nil
+	}
+	return parentPath, nil
+}
+
+// DeletedTime 文件删除时间。回收站接口没有单独的删除时间字段，使用最后修改时间 LastOpTime 代替，
+// 文件删除时会更新该时间，但是不保证完全等同于删除时间。时间为空或者无法解析时返回零值
+func (r *RecycleFileInfo) DeletedTime() time.Time {
+	return *MustParseTime(r.LastOpTime)
+}
+
+// Match 回收站文件是否满足过滤条件
+func (f *RecycleFilter) Match(r *RecycleFileInfo) bool {
+	if r == nil {
+		return false
+	}
+	if f.PathPrefix != "" {
+		prefix := path.Clean("/" + f.PathPrefix)
+		parentPath := r.OriginalParentPath()
+		if prefix != "/" && parentPath != prefix && !strings.HasPrefix(parentPath, prefix+"/") {
+			return false
+		}
+	}
+	if f.NamePattern != "" {
+		if ok, _ := path.Match(f.NamePattern, r.FileName); !ok {
+			return false
+		}
+	}
+	if !f.DeletedAfter.IsZero() || !f.DeletedBefore.IsZero() {
+		deletedTime := r.DeletedTime()
+		if deletedTime.IsZero() {
+			// 删除时间未知，不满足时间条件
+			return false
+		}
+		if !f.DeletedAfter.IsZero() && deletedTime.Before(f.DeletedAfter) {
+			return false
+		}
+		if !f.DeletedBefore.IsZero() && deletedTime.After(f.DeletedBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// RecycleFind 查找回收站中满足过滤条件的文件，familyId为0则查找个人云回收站
+func (p *PanClient) RecycleFind(ctx context.Context, familyId int64, filter *RecycleFilter) (RecycleFileInfoList, *apierror.ApiError) {
+	fileList := RecycleFileInfoList{}
+	c := p.RecycleListCursor(ctx, familyId, 100)
+	for c.Next() {
+		if filter == nil || filter.Match(c.Entry()) {
+			fileList = append(fileList, c.Entry())
+		}
+	}
+	return fileList, c.Err()
+}
+
+// conflictDealWayOf 将冲突处理策略转换为批量任务的冲突处理方式
+func conflictDealWayOf(policy ConflictPolicy) (BatchTaskDealWay, bool) {
+	switch policy {
+	case ConflictPolicySkip:
+		return BatchTaskDealWaySkip, true
+	case ConflictPolicyOverwrite:
+		return BatchTaskDealWayOverwrite, true
+	case ConflictPolicyAutoRename:
+		return BatchTaskDealWayKeepBoth, true
+	}
+	return 0, false
+}
+
+// RecycleRestoreTo 还原回收站文件并等待完成，支持还原到其他文件夹、重新创建原始父文件夹以及同名冲突处理
+// 文件先还原到原始位置，再移动到 TargetFolderId 或者重新创建的原始父文件夹，指定 TargetFolderId 时忽略 RecreateParents
+func (p *PanClient) RecycleRestoreTo(ctx context.Context, param *RecycleRestoreParam) *apierror.ApiError {
+	if len(param.FileList) == 0 {
+		return nil
+	}
+
+	// 还原前记录原始父文件夹不存在的文件，还原后再移动到重新创建的文件夹
+	pd := p.PathDrive(param.FamilyId)
+	missingParents := map[string]bool{}
+	parentPaths := map[*RecycleFileInfo]string{}
+	if param.TargetFolderId == "" && param.RecreateParents {
+		for _, r := range param.FileList {
+			parentPath, err := pd.recycleParentPath(r)
+			if err != nil {
+				return err
+			}
+			parentPaths[r] = parentPath
+			if _, ok := missingParents[parentPath]; ok {
+				continue
+			}
+			fi, err := pd.exists(parentPath)
+			if err != nil {
+				return err
+			}
+			missingParents[parentPath] = fi == nil
+		}
+	}
+
+	runOpts := NewBatchTaskRunOption()
+	runOpts.FamilyId = param.FamilyId
+	if param.TargetFolderId == "" {
+		// 还原到原始位置才可能和原位置文件冲突，还原到其他文件夹时在移动时处理冲突
+		if dealWay, ok := conflictDealWayOf(param.Policy); ok {
+			runOpts.OnConflict = p.BatchTaskConflictResolver(BatchTaskConflictDealWayAll(dealWay))
+		}
+	} else {
+		runOpts.OnConflict = p.BatchTaskConflictResolver(BatchTaskConflictDealWayAll(BatchTaskDealWayKeepBoth))
+	}
+	_, err := p.RunBatchTask(ctx, &BatchTaskParam{
+		TypeFlag:  BatchTaskTypeRecycleRestore,
+		TaskInfos: makeBatchTaskInfoList(param.FileList),
+	}, runOpts)
+	if err != nil {
+		return err
+	}
+
+	// 按目标文件夹分组需要移动的文件
+	targetFolderIds := []string{}
+	moveFiles := map[string]RecycleFileInfoList{}
+	for _, r := range param.FileList {
+		targetFolderId := param.TargetFolderId
+		if targetFolderId == "" {
+			parentPath, ok := parentPaths[r]
+			if !ok || !missingParents[parentPath] {
+				continue
+			}
+			folder, err := pd.MkdirAll(parentPath)
+			if err != nil {
+				return err
+			}
+			targetFolderId = folder.FileId
+		}
+		if _, ok := moveFiles[targetFolderId]; !ok {
+			targetFolderIds = append(targetFolderIds, targetFolderId)
+		}
+		moveFiles[targetFolderId] = append(moveFiles[targetFolderId], r)
+	}
+
+	drive := p.Family(param.FamilyId)
+	for _, targetFolderId := range targetFolderIds {
+		// 还原后文件ID不变，但是同名冲突时文件可能被重命名，需要重新获取文件详情
+		fileList := AppFileList{}
+		for _, r := range moveFiles[targetFolderId] {
+			fi, err := drive.Stat(strconv.FormatInt(r.FileId, 10))
+			if err != nil {
+				return err
+			}
+			if fi == nil {
+				return apierror.NewFailedApiError("找不到还原后的文件：" + r.FileName)
+			}
+			if fi.ParentId == targetFolderId {
+				continue
+			}
+			fileList = append(fileList, fi)
+		}
+		if len(fileList) == 0 {
+			continue
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return apierror.NewFailedApiError("还原文件被取消: " + ctx.Err().Error())
+		}
+		logger.Verboseln("move restored files to ", targetFolderId)
+		if err := p.MoveFileWithPolicy(param.FamilyId, fileList, targetFolderId, param.Policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
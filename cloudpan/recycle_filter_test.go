@@ -0,0 +1,42 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecycleFilterMatch(t *testing.T) {
+	r := &RecycleFileInfo{
+		FileName:   "a.jpg",
+		PathStr:    "/我的图片/2021",
+		LastOpTime: "2021-03-01 10:00:00",
+	}
+	assert.Equal(t, "/我的图片/2021", r.OriginalParentPath())
+	assert.Equal(t, "/我的图片/2021", (&RecycleFileInfo{FileName: "a.jpg", PathStr: "/我的图片/2021/a.jpg"}).OriginalParentPath())
+	assert.Equal(t, "/我的图片", (&RecycleFileInfo{FileName: "2021", PathStr: "/我的图片/2021"}).OriginalParentPath())
+	assert.Equal(t, "/", (&RecycleFileInfo{FileName: "a.jpg", PathStr: "/a.jpg"}).OriginalParentPath())
+	assert.Equal(t, "/", (&RecycleFileInfo{FileName: "a.jpg"}).OriginalParentPath())
+
+	assert.True(t, (&RecycleFilter{}).Match(r))
+	assert.True(t, (&RecycleFilter{PathPrefix: "/我的图片"}).Match(r))
+	assert.False(t, (&RecycleFilter{PathPrefix: "/我的"}).Match(r))
+	assert.True(t, (&RecycleFilter{NamePattern: "*.jpg"}).Match(r))
+	assert.False(t, (&RecycleFilter{NamePattern: "*.png"}).Match(r))
+	assert.True(t, (&RecycleFilter{DeletedAfter: *MustParseTime("2021-02-01 00:00:00")}).Match(r))
+	assert.False(t, (&RecycleFilter{DeletedBefore: *MustParseTime("2021-02-01 00:00:00")}).Match(r))
+	assert.False(t, (&RecycleFilter{DeletedBefore: *MustParseTime("2021-02-01 00:00:00")}).Match(&RecycleFileInfo{FileName: "b.jpg"}))
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/converter"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// RecyclePurgePolicy 回收站清理策略，满足任意一个条件的文件会被彻底删除，没有设置任何条件则不删除任何文件
+	RecyclePurgePolicy struct {
+		// OlderThanDays 删除超过指定天数的文件，0代表不按时间清理。
+		// 回收站接口没有返回删除时间，LastOpTime 不等同于删除时间，目前设置该条件会直接返回错误
+		OlderThanDays int
+		// LargerThan 大于指定字节数的文件，0代表不按大小清理
+		LargerThan int64
+		// Patterns 文件名通配符或者以"/"开头的原始路径通配符
+		Patterns []string
+		// IncludeFamilies 是否同时清理所有家庭云的回收站
+		IncludeFamilies bool
+		// DryRun 只列出需要清理的文件，不实际删除
+		DryRun bool
+		// AuditWriter 审计日志输出，每个清理的文件输出一行，可以为nil
+		AuditWriter io.Writer
+	}
+
+	// RecyclePurgeRecord 回收站清理记录
+	RecyclePurgeRecord struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		FileInfo *RecycleFileInfo
+		// Reason 清理原因
+		Reason string
+		// Purged 是否已经彻底删除，DryRun时为false
+		Purged bool
+		Err    *apierror.ApiError
+	}
+)
+
+// validate 检查清理策略，回收站接口没有删除时间，不支持按删除天数清理
+func (policy *RecyclePurgePolicy) validate() *apierror.ApiError {
+	if policy.OlderThanDays > 0 {
+		return apierror.NewFailedApiError("回收站接口没有返回文件的删除时间，暂不支持按删除天数清理")
+	}
+	return nil
+}
+
+// reasonOf 判断回收站文件是否需要清理，不需要清理返回空字符串
+func (policy *RecyclePurgePolicy) reasonOf(r *RecycleFileInfo) string {
+	if policy.LargerThan > 0 && r.FileSize > policy.LargerThan {
+		return "文件大于" + converter.ConvertFileSize(policy.LargerThan, 2)
+	}
+	for _, pattern := range policy.Patterns {
+		name := r.FileName
+		if strings.HasPrefix(pattern, "/") {
+			name = path.Join(r.OriginalParentPath(), r.FileName)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return "匹配规则" + pattern
+		}
+	}
+	return ""
+}
+
+func (policy *RecyclePurgePolicy) audit(record *RecyclePurgeRecord) {
+	if policy.AuditWriter == nil {
+		return
+	}
+	action := "purged"
+	if record.Err != nil {
+		action = "failed: " + record.Err.Error()
+	} else if policy.DryRun {
+		action = "dry-run"
+	}
+	fi := record.FileInfo
+	fmt.Fprintf(policy.AuditWriter, "%s\tfamily=%d\tid=%d\tpath=%s\tsize=%d\tlastOpTime=%s\treason=%s\t%s\n",
+		time.Now().Format("2006-01-02 15:04:05"), record.FamilyId, fi.FileId,
+		path.Join(fi.OriginalParentPath(), fi.FileName), fi.FileSize, fi.LastOpTime, record.Reason, action)
+}
+
+// RecyclePurge 按照清理策略彻底删除回收站中的文件，返回所有清理记录
+func (p *PanClient) RecyclePurge(ctx context.Context, policy *RecyclePurgePolicy) ([]*RecyclePurgeRecord, *apierror.ApiError) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	familyIds := []int64{0}
+	if policy.IncludeFamilies {
+		familyList, err := p.AppFamilyGetFamilyList()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range familyList.FamilyInfoList {
+			if f == nil {
+				continue
+			}
+			familyIds = append(familyIds, f.FamilyId)
+		}
+	}
+
+	records := []*RecyclePurgeRecord{}
+	failed := false
+	for _, familyId := range familyIds {
+		r, err := p.recyclePurgeFamily(ctx, familyId, policy)
+		records = append(records, r...)
+		if err != nil {
+			return records, err
+		}
+		for _, record := range r {
+			if record.Err != nil {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		return records, apierror.NewFailedApiError("部分回收站文件清理失败")
+	}
+	return records, nil
+}
+
+func (p *PanClient) recyclePurgeFamily(ctx context.Context, familyId int64, policy *RecyclePurgePolicy) ([]*RecyclePurgeRecord, *apierror.ApiError) {
+	records := []*RecyclePurgeRecord{}
+	c := p.RecycleListCursor(ctx, familyId, 100)
+	for c.Next() {
+		reason := policy.reasonOf(c.Entry())
+		if reason == "" {
+			continue
+		}
+		records = append(records, &RecyclePurgeRecord{
+			FamilyId: familyId,
+			FileInfo: c.Entry(),
+			Reason:   reason,
+		})
+	}
+	if c.Err() != nil {
+		return nil, c.Err()
+	}
+
+	if policy.DryRun {
+		for _, record := range records {
+			policy.audit(record)
+		}
+		return records, nil
+	}
+
+	var cancelErr *apierror.ApiError
+	for _, r := range chunkRanges(len(records), defaultBatchChunkSize) {
+		chunk := records[r[0]:r[1]]
+		var err *apierror.ApiError
+		if cancelErr == nil && ctx != nil && ctx.Err() != nil {
+			cancelErr = apierror.NewFailedApiError("清理回收站被取消: " + ctx.Err().Error())
+		}
+		if cancelErr != nil {
+			err = cancelErr
+		} else {
+			fileIdList := []string{}
+			for _, record := range chunk {
+				fileIdList = append(fileIdList, strconv.FormatInt(record.FileInfo.FileId, 10))
+			}
+			err = p.RecycleDelete(familyId, fileIdList)
+		}
+		for _, record := range chunk {
+			record.Err = err
+			record.Purged = err == nil
+			policy.audit(record)
+		}
+	}
+	return records, cancelErr
+}
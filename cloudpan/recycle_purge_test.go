@@ -0,0 +1,39 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRecyclePurgePolicyReason(t *testing.T) {
+	r := &RecycleFileInfo{
+		FileName:   "a.log",
+		FileSize:   1024,
+		PathStr:    "/logs",
+		LastOpTime: "2021-03-01 00:00:00",
+	}
+
+	assert.Equal(t, "", (&RecyclePurgePolicy{}).reasonOf(r))
+	assert.NotEqual(t, "", (&RecyclePurgePolicy{LargerThan: 100}).reasonOf(r))
+	assert.Equal(t, "", (&RecyclePurgePolicy{LargerThan: 4096}).reasonOf(r))
+	assert.NotEqual(t, "", (&RecyclePurgePolicy{Patterns: []string{"*.log"}}).reasonOf(r))
+	assert.NotEqual(t, "", (&RecyclePurgePolicy{Patterns: []string{"/logs/*"}}).reasonOf(r))
+	assert.Equal(t, "", (&RecyclePurgePolicy{Patterns: []string{"/tmp/*"}}).reasonOf(r))
+
+	assert.Nil(t, (&RecyclePurgePolicy{LargerThan: 100}).validate())
+	assert.NotNil(t, (&RecyclePurgePolicy{OlderThanDays: 7}).validate())
+}
@@ -26,8 +26,9 @@ import (
 )
 
 type (
-	ShareExpiredTime int
-	ShareMode        int
+	ShareExpiredTime  int
+	ShareMode         int
+	ShareReviewStatus int
 
 	PrivateShareResult struct {
 		AccessCode    string `json:"accessCode"`
@@ -75,8 +76,8 @@ type (
 		NeedAccessCode int       `json:"needAccessCode"`
 		// NickName 分享者账号昵称
 		NickName string `json:"nickName"`
-		// ReviewStatus 审查状态，1-正常
-		ReviewStatus int `json:"reviewStatus"`
+		// ReviewStatus 审查状态
+		ReviewStatus ShareReviewStatus `json:"reviewStatus"`
 		// ShareDate 分享日期
 		ShareDate int64 `json:"shareDate"`
 		// ShareId 分享项目ID，唯一标识该分享项
@@ -89,6 +90,8 @@ type (
 		ShareType int `json:"shareType"`
 		// ShortShareUrl 分享的访问路径，和 AccessURL 一致
 		ShortShareUrl string `json:"shortShareUrl"`
+		// ExpireTime 分享有效期天数，见 ShareExpiredTime
+		ExpireTime ShareExpiredTime `json:"expireTime"`
 	}
 
 	ShareItemList []*ShareItem
@@ -111,9 +114,17 @@ type (
 		ErrorVO apierror.ErrorResp `json:"errorVO"`
 	}
 
-	// 转存分享
+	shareLink struct {
+		AccessCode string `json:"accessCode"`
+		AccessUrl  string `json:"accessUrl"`
+		FileId     int64  `json:"fileId"`
+		ShareId    int64  `json:"shareId"`
+		Url        string `json:"url"`
+	}
+
+	// listShareDirResult 分享文件夹列表响应体
 	listShareDirResult struct {
-		ResCode    int    `json:"res_code"`
+		ResCode    String `json:"res_code"`
 		ResMessage string `json:"res_message"`
 		ExpireTime int    `json:"expireTime"`
 		ExpireType int    `json:"expireType"`
@@ -153,9 +164,18 @@ const (
 	ShareExpiredTime1Day ShareExpiredTime = 1
 	// 7天期限
 	ShareExpiredTime7Day ShareExpiredTime = 7
+	// 30天期限
+	ShareExpiredTime30Day ShareExpiredTime = 30
 	// 永久期限
 	ShareExpiredTimeForever ShareExpiredTime = 2099
 
+	// ShareReviewStatusAuditing 审核中
+	ShareReviewStatusAuditing ShareReviewStatus = 0
+	// ShareReviewStatusNormal 正常
+	ShareReviewStatusNormal ShareReviewStatus = 1
+	// ShareReviewStatusBlocked 审核未通过，分享已被屏蔽
+	ShareReviewStatusBlocked ShareReviewStatus = 2
+
 	// ShareModePrivate 私密分享
 	ShareModePrivate ShareMode = 1
 	// ShareModePublic 公开分享
@@ -163,9 +183,36 @@ const (
 )
 
 func (p *PanClient) SharePrivate(fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError) {
+	return p.sharePrivate(0, fileId, expiredTime)
+}
+
+// FamilySharePrivate 私密分享家庭云文件
+func (p *PanClient) FamilySharePrivate(familyId int64, fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError) {
+	if familyId <= 0 {
+		return nil, apierror.NewFailedApiError("家庭云ID无效")
+	}
+	return p.sharePrivate(familyId, fileId, expiredTime)
+}
+
+func (p *PanClient) sharePrivate(familyId int64, fileId string, expiredTime ShareExpiredTime) (*PrivateShareResult, *apierror.ApiError) {
+	link, err := p.createShareLink(familyId, fileId, expiredTime)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateShareResult{
+		AccessCode:    link.AccessCode,
+		ShortShareUrl: link.Url,
+	}, nil
+}
+
+// createShareLink 创建私密分享链接
+func (p *PanClient) createShareLink(familyId int64, fileId string, expiredTime ShareExpiredTime) (*shareLink, *apierror.ApiError) {
 	fullUrl := &strings.Builder{}
 	fmt.Fprintf(fullUrl, "%s/api/open/share/createShareLink.action?fileId=%s&expireTime=%d&shareType=3",
 		WEB_URL, fileId, expiredTime)
+	if familyId > 0 {
+		fmt.Fprintf(fullUrl, "&familyId=%d", familyId)
+	}
 	logger.Verboseln("do request url: " + fullUrl.String())
 	//body, err := p.client.DoGet(fullUrl.String())
 	headers := map[string]string{
@@ -188,13 +235,6 @@ func (p *PanClient) SharePrivate(fileId string, expiredTime ShareExpiredTime) (*
 		}
 	}
 
-	type shareLink struct {
-		AccessCode string `json:"accessCode"`
-		AccessUrl  string `json:"accessUrl"`
-		FileId     int64  `json:"fileId"`
-		ShareId    int64  `json:"shareId"`
-		Url        string `json:"url"`
-	}
 	type shareLinkResult struct {
 		Code          int         `json:"res_code"`
 		Message       string      `json:"res_message"`
@@ -205,10 +245,10 @@ func (p *PanClient) SharePrivate(fileId string, expiredTime ShareExpiredTime) (*
 		logger.Verboseln("SharePrivate response failed")
 		return nil, apierror.NewApiErrorWithError(err)
 	}
-	return &PrivateShareResult{
-		AccessCode:    r.ShareLinkList[0].AccessCode,
-		ShortShareUrl: r.ShareLinkList[0].Url,
-	}, nil
+	if len(r.ShareLinkList) == 0 {
+		return nil, apierror.NewFailedApiError("创建分享失败")
+	}
+	return &r.ShareLinkList[0], nil
 }
 
 func (p *PanClient) SharePublic(fileId string, expiredTime ShareExpiredTime) (*PublicShareResult, *apierror.ApiError) {
@@ -294,104 +334,36 @@ func (p *PanClient) ShareCancel(shareIdList []int64) (bool, *apierror.ApiError)
 
 // ShareSave 转存分享到对应的文件夹
 func (p *PanClient) ShareSave(accessUrl string, accessCode string, savePanDirId string) (bool, *apierror.ApiError) {
-	shareCode := ""
-	idx := strings.LastIndex(accessUrl, "/")
-	if idx > 0 {
-		rs := []rune(accessUrl)
-		shareCode = string(rs[idx+1:])
-	}
-	fullUrl := &strings.Builder{}
-	header := map[string]string{
-		"accept":     "application/json;charset=UTF-8",
-		"origin":     "https://cloud.189.cn",
-		"Referer":    "https://cloud.189.cn/web/share?code=" + shareCode,
-		"user-agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 11_3_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.96 Safari/537.36",
-	}
-
 	// 获取分享基础信息
-	fmt.Fprintf(fullUrl, "%s/api/open/share/getShareInfoByCode.action?&shareCode=%s",
-		WEB_URL, shareCode)
-
-	logger.Verboseln("do request url: " + fullUrl.String())
-	body, err := client.Fetch("GET", fullUrl.String(), nil, header)
+	shareInfo, err := p.GetShareInfo(accessUrl, accessCode)
 	if err != nil {
-		logger.Verboseln("ShareListDirDetail failed")
-		return false, apierror.NewApiErrorWithError(err)
-	}
-
-	type shareInfoByCode struct {
-		ResCode        int    `json:"res_code"`
-		ResMessage     string `json:"res_message"`
-		AccessCode     string `json:"accessCode"`
-		ExpireTime     int    `json:"expireTime"`
-		ExpireType     int    `json:"expireType"`
-		FileId         string `json:"fileId"`
-		FileName       string `json:"fileName"`
-		FileSize       int    `json:"fileSize"`
-		IsFolder       bool   `json:"isFolder"`
-		NeedAccessCode int    `json:"needAccessCode"`
-		ShareDate      int64  `json:"shareDate"`
-		ShareId        int64  `json:"shareId"`
-		ShareMode      int    `json:"shareMode"`
-		ShareType      int    `json:"shareType"`
-	}
-	shareInfoEnity := &shareInfoByCode{}
-	if err := json.Unmarshal(body, shareInfoEnity); err != nil {
-		logger.Verboseln("getShareInfoByCode response failed")
-		return false, apierror.NewApiErrorWithError(err)
+		return false, err
 	}
 
 	// 获取分享文件列表
-	fullUrl = &strings.Builder{}
-	if shareInfoEnity.IsFolder {
-		fmt.Fprintf(fullUrl, "%s/api/open/share/listShareDir.action?pageNum=1&pageSize=60&fileId=%s&shareDirFileId=%s&isFolder=true&shareId=%d&shareMode=%d&iconOption=5&orderBy=lastOpTime&descending=true&accessCode=%s",
-			WEB_URL, shareInfoEnity.FileId, shareInfoEnity.FileId, shareInfoEnity.ShareId, shareInfoEnity.ShareMode, accessCode)
-	} else {
-		fmt.Fprintf(fullUrl, "%s/api/open/share/listShareDir.action?fileId=%s&shareId=%d&shareMode=%d&isFolder=false&iconOption=5&pageNum=1&pageSize=10&accessCode=%s",
-			WEB_URL, shareInfoEnity.FileId, shareInfoEnity.ShareId, shareInfoEnity.ShareMode, accessCode)
-	}
-	logger.Verboseln("do request url: " + fullUrl.String())
-	body, err = client.Fetch("GET", fullUrl.String(), nil, header)
+	entryList, err := p.ListShareDirAll(shareInfo, "", "/", false)
 	if err != nil {
-		logger.Verboseln("listShareDir failed")
-		return false, apierror.NewApiErrorWithError(err)
-	}
-
-	listShareDirEnity := &listShareDirResult{}
-	if err := json.Unmarshal(body, listShareDirEnity); err != nil {
-		logger.Verboseln("listShareDir response failed")
-		return false, apierror.NewApiErrorWithError(err)
+		return false, err
 	}
 
 	// 转存分享
 	taskReqParam := &BatchTaskParam{
 		TypeFlag:       BatchTaskTypeShareSave,
-		TaskInfos:      makeBatchTaskInfoListForShareSave(listShareDirEnity),
+		TaskInfos:      makeBatchTaskInfoListForShareSave(entryList),
 		TargetFolderId: savePanDirId,
-		ShareId:        shareInfoEnity.ShareId,
+		ShareId:        shareInfo.ShareId,
 	}
 	taskId, apierror1 := p.CreateBatchTask(taskReqParam)
 	logger.Verboseln("share save taskid: ", taskId)
 	return taskId != "", apierror1
 }
 
-func makeBatchTaskInfoListForShareSave(opFileList *listShareDirResult) (infoList BatchTaskInfoList) {
-	// file
-	for _, fe := range opFileList.FileListAO.FileList {
-		infoItem := &BatchTaskInfo{
-			FileId:   strconv.FormatInt(fe.Id, 10),
-			FileName: fe.Name,
-			IsFolder: 0,
-		}
-		infoList = append(infoList, infoItem)
-	}
-
-	// folder
-	for _, fe := range opFileList.FileListAO.FolderList {
+func makeBatchTaskInfoListForShareSave(entryList ShareDirEntryList) (infoList BatchTaskInfoList) {
+	for _, fe := range entryList {
 		infoItem := &BatchTaskInfo{
-			FileId:   strconv.FormatInt(fe.Id, 10),
-			FileName: fe.Name,
-			IsFolder: 1,
+			FileId:   fe.FileId,
+			FileName: fe.FileName,
+			IsFolder: BoolToNumber(fe.IsFolder),
 		}
 		infoList = append(infoList, infoItem)
 	}
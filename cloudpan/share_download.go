@@ -0,0 +1,80 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"strings"
+)
+
+// GetShareFileDownloadUrl 获取分享中文件的下载链接，无需转存到自己的云盘，accessCode为私密分享的访问码
+func (p *PanClient) GetShareFileDownloadUrl(shareId int64, fileId, accessCode string) (string, *apierror.ApiError) {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/api/open/file/getFileDownloadUrl.action?fileId=%s&dt=1&shareId=%d",
+		WEB_URL, fileId, shareId)
+	if accessCode != "" {
+		fmt.Fprintf(fullUrl, "&accessCode=%s", url.QueryEscape(accessCode))
+	}
+	logger.Verboseln("do request url: " + fullUrl.String())
+	headers := map[string]string{
+		"accept": "application/json;charset=UTF-8",
+	}
+	body, err := p.client.Fetch("GET", fullUrl.String(), nil, headers)
+	if err != nil {
+		logger.Verboseln("GetShareFileDownloadUrl failed")
+		return "", apierror.NewApiErrorWithError(err)
+	}
+	type downloadUrlResp struct {
+		ResCode         String `json:"res_code"`
+		ResMessage      string `json:"res_message"`
+		FileDownloadUrl string `json:"fileDownloadUrl"`
+	}
+	item := &downloadUrlResp{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("GetShareFileDownloadUrl response failed")
+		return "", apierror.NewApiErrorWithError(err)
+	}
+	if apiErr := shareApiError(item.ResCode, item.ResMessage); apiErr != nil {
+		return "", apiErr
+	}
+	if item.FileDownloadUrl == "" {
+		return "", apierror.NewFailedApiError("获取下载链接失败")
+	}
+	return strings.ReplaceAll(item.FileDownloadUrl, "&amp;", "&"), nil
+}
+
+// GetShareLinkDownloadUrl 通过分享链接获取分享中文件的下载链接，fileId为空则获取单文件分享的文件
+func (p *PanClient) GetShareLinkDownloadUrl(accessUrl, accessCode, fileId string) (string, *apierror.ApiError) {
+	shareInfo, err := p.GetShareInfo(accessUrl, accessCode)
+	if err != nil {
+		return "", err
+	}
+	if fileId == "" {
+		if shareInfo.IsFolder {
+			return "", apierror.NewFailedApiError("分享的是文件夹，请指定需要下载的文件")
+		}
+		fileId = shareInfo.FileId
+	}
+	return p.GetShareFileDownloadUrl(shareInfo.ShareId, fileId, shareInfo.AccessCode)
+}
+
+// ShareDownloadFileData 下载分享中文件的数据，支持断点续传，下载方式和自己云盘的文件相同
+func (p *PanClient) ShareDownloadFileData(downloadFileUrl string, fileRange AppFileDownloadRange, downloadFunc DownloadFuncCallback) *apierror.ApiError {
+	return p.AppDownloadFileData(downloadFileUrl, fileRange, downloadFunc)
+}
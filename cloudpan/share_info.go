@@ -0,0 +1,283 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type (
+	// ShareInfo 分享链接信息
+	ShareInfo struct {
+		// ShareCode 分享码，分享链接的最后一段
+		ShareCode string `json:"-"`
+		// AccessCode 访问码，私密分享才需要
+		AccessCode string `json:"accessCode"`
+		// ExpireTime 剩余有效天数
+		ExpireTime int `json:"expireTime"`
+		// ExpireType 有效期类型
+		ExpireType int `json:"expireType"`
+		// FileId 分享的文件/文件夹ID
+		FileId string `json:"fileId"`
+		// FileName 分享的文件/文件夹名称
+		FileName string `json:"fileName"`
+		// FileSize 文件大小，文件夹为0
+		FileSize int64 `json:"fileSize"`
+		// IsFolder 是否是文件夹
+		IsFolder bool `json:"isFolder"`
+		// NeedAccessCode 是否需要访问码，1-需要
+		NeedAccessCode int `json:"needAccessCode"`
+		// ShareDate 分享日期
+		ShareDate int64 `json:"shareDate"`
+		// ShareId 分享ID
+		ShareId int64 `json:"shareId"`
+		// ShareMode 分享模式，1-私密，2-公开
+		ShareMode ShareMode `json:"shareMode"`
+		// ShareType 分享类别
+		ShareType int `json:"shareType"`
+	}
+
+	// ShareDirEntry 分享中的文件/文件夹
+	ShareDirEntry struct {
+		// FileId 文件ID
+		FileId string
+		// ParentId 父文件夹ID
+		ParentId string
+		// FileName 文件名
+		FileName string
+		// FileSize 文件大小，文件夹为0
+		FileSize int64
+		// Md5 文件MD5，文件夹为空
+		Md5 string
+		// IsFolder 是否是文件夹
+		IsFolder bool
+		// MediaType 媒体类型
+		MediaType MediaType
+		// CreateDate 创建时间
+		CreateDate string
+		// LastOpTime 最后修改时间
+		LastOpTime string
+		// Path 在分享中的路径，以"/"开头
+		Path string
+	}
+
+	ShareDirEntryList []*ShareDirEntry
+
+	// ShareDirListResult 分享文件夹列表
+	ShareDirListResult struct {
+		// Count 文件夹下的文件总数量
+		Count int
+		// ExpireTime 分享剩余有效天数
+		ExpireTime int
+		// ExpireType 分享有效期类型
+		ExpireType int
+		// EntryList 当前页的文件列表
+		EntryList ShareDirEntryList
+	}
+
+	shareInfoResp struct {
+		ShareInfo
+		ResCode    String `json:"res_code"`
+		ResMessage string `json:"res_message"`
+	}
+)
+
+// parseShareCode 从分享链接中解析分享码，支持 https://cloud.189.cn/t/xxx 和 https://cloud.189.cn/web/share?code=xxx 两种格式
+func parseShareCode(accessUrl string) string {
+	accessUrl = strings.TrimSpace(accessUrl)
+	if u, err := url.Parse(accessUrl); err == nil {
+		if code := u.Query().Get("code"); code != "" {
+			return code
+		}
+	}
+	idx := strings.LastIndex(accessUrl, "/")
+	if idx >= 0 {
+		accessUrl = accessUrl[idx+1:]
+	}
+	if idx = strings.IndexAny(accessUrl, "?#"); idx >= 0 {
+		accessUrl = accessUrl[:idx]
+	}
+	return accessUrl
+}
+
+// shareApiError 解析分享接口的错误码，没有错误则返回nil
+func shareApiError(resCode String, resMessage string) *apierror.ApiError {
+	code := string(resCode)
+	switch code {
+	case "", "0":
+		return nil
+	case "ShareExpiredError", "ShareExpired":
+		return apierror.NewApiError(apierror.ApiCodeShareExpired, "分享已过期")
+	case "ShareAuditWaiting", "ShareAuditNotPass":
+		return apierror.NewApiError(apierror.ApiCodeShareAudited, "分享审核中或者审核未通过")
+	case "ShareAccessCodeError", "InvalidAccessCode", "NeedAccessCode":
+		return apierror.NewApiError(apierror.ApiCodeShareNeedAccessCode, "分享访问码错误")
+	case "ShareNotFound", "ShareInfoNotFound", "ShareNotFoundFlatDir", "ShareCanceled":
+		return apierror.NewApiError(apierror.ApiCodeShareNotFound, "分享不存在或者已取消")
+	}
+	if resMessage == "" {
+		resMessage = code
+	}
+	return apierror.NewFailedApiError(resMessage)
+}
+
+func shareRequestHeader(shareCode string) map[string]string {
+	return map[string]string{
+		"accept":     "application/json;charset=UTF-8",
+		"origin":     "https://cloud.189.cn",
+		"Referer":    "https://cloud.189.cn/web/share?code=" + shareCode,
+		"user-agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 11_3_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.96 Safari/537.36",
+	}
+}
+
+// GetShareInfo 获取分享链接的基础信息，accessCode为私密分享的访问码
+func (p *PanClient) GetShareInfo(accessUrl, accessCode string) (*ShareInfo, *apierror.ApiError) {
+	shareCode := parseShareCode(accessUrl)
+	if shareCode == "" {
+		return nil, apierror.NewFailedApiError("分享链接错误")
+	}
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/api/open/share/getShareInfoByCode.action?shareCode=%s",
+		WEB_URL, url.QueryEscape(shareCode))
+	logger.Verboseln("do request url: " + fullUrl.String())
+	body, err := client.Fetch("GET", fullUrl.String(), nil, shareRequestHeader(shareCode))
+	if err != nil {
+		logger.Verboseln("GetShareInfo failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	item := &shareInfoResp{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("GetShareInfo response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	if apiErr := shareApiError(item.ResCode, item.ResMessage); apiErr != nil {
+		return nil, apiErr
+	}
+	info := &item.ShareInfo
+	info.ShareCode = shareCode
+	if accessCode != "" {
+		info.AccessCode = accessCode
+	}
+	return info, nil
+}
+
+// ListShareDir 获取分享中文件夹的一页文件列表，dirFileId为空则列出分享的根目录
+func (p *PanClient) ListShareDir(info *ShareInfo, dirFileId string, pageNum, pageSize int) (*ShareDirListResult, *apierror.ApiError) {
+	if info == nil {
+		return nil, apierror.NewFailedApiError("分享信息为空")
+	}
+	if info.NeedAccessCode == 1 && info.AccessCode == "" {
+		return nil, apierror.NewApiError(apierror.ApiCodeShareNeedAccessCode, "分享需要访问码")
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 60
+	}
+	fullUrl := &strings.Builder{}
+	if info.IsFolder || dirFileId != "" {
+		if dirFileId == "" {
+			dirFileId = info.FileId
+		}
+		fmt.Fprintf(fullUrl, "%s/api/open/share/listShareDir.action?pageNum=%d&pageSize=%d&fileId=%s&shareDirFileId=%s&isFolder=true&shareId=%d&shareMode=%d&iconOption=5&orderBy=lastOpTime&descending=true&accessCode=%s",
+			WEB_URL, pageNum, pageSize, dirFileId, dirFileId, info.ShareId, info.ShareMode, url.QueryEscape(info.AccessCode))
+	} else {
+		fmt.Fprintf(fullUrl, "%s/api/open/share/listShareDir.action?fileId=%s&shareId=%d&shareMode=%d&isFolder=false&iconOption=5&pageNum=%d&pageSize=%d&accessCode=%s",
+			WEB_URL, info.FileId, info.ShareId, info.ShareMode, pageNum, pageSize, url.QueryEscape(info.AccessCode))
+	}
+	logger.Verboseln("do request url: " + fullUrl.String())
+	body, err := client.Fetch("GET", fullUrl.String(), nil, shareRequestHeader(info.ShareCode))
+	if err != nil {
+		logger.Verboseln("ListShareDir failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	item := &listShareDirResult{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("ListShareDir response failed")
+		return nil, apierror.NewApiErrorWithError(err)
+	}
+	if apiErr := shareApiError(item.ResCode, item.ResMessage); apiErr != nil {
+		return nil, apiErr
+	}
+
+	result := &ShareDirListResult{
+		Count:      item.FileListAO.Count,
+		ExpireTime: item.ExpireTime,
+		ExpireType: item.ExpireType,
+		EntryList:  ShareDirEntryList{},
+	}
+	for _, fe := range item.FileListAO.FolderList {
+		result.EntryList = append(result.EntryList, &ShareDirEntry{
+			FileId:     strconv.FormatInt(fe.Id, 10),
+			ParentId:   dirFileId,
+			FileName:   fe.Name,
+			IsFolder:   true,
+			CreateDate: fe.CreateDate,
+			LastOpTime: fe.LastOpTime,
+		})
+	}
+	for _, fe := range item.FileListAO.FileList {
+		result.EntryList = append(result.EntryList, &ShareDirEntry{
+			FileId:     strconv.FormatInt(fe.Id, 10),
+			ParentId:   dirFileId,
+			FileName:   fe.Name,
+			FileSize:   fe.Size,
+			Md5:        fe.Md5,
+			IsFolder:   false,
+			MediaType:  MediaType(fe.MediaType),
+			CreateDate: fe.CreateDate,
+			LastOpTime: fe.LastOpTime,
+		})
+	}
+	return result, nil
+}
+
+// ListShareDirAll 获取分享中文件夹的所有文件，自动翻页，recursive为true则递归获取子文件夹
+func (p *PanClient) ListShareDirAll(info *ShareInfo, dirFileId, dirPath string, recursive bool) (ShareDirEntryList, *apierror.ApiError) {
+	if dirPath == "" {
+		dirPath = "/"
+	}
+	entryList := ShareDirEntryList{}
+	pageSize := 100
+	for pageNum := 1; ; pageNum++ {
+		r, err := p.ListShareDir(info, dirFileId, pageNum, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range r.EntryList {
+			entry.Path = path.Join(dirPath, entry.FileName)
+			entryList = append(entryList, entry)
+			if recursive && entry.IsFolder {
+				subList, err := p.ListShareDirAll(info, entry.FileId, entry.Path, recursive)
+				if err != nil {
+					return nil, err
+				}
+				entryList = append(entryList, subList...)
+			}
+		}
+		if len(r.EntryList) == 0 || !hasMorePage(pageNum, pageSize, r.Count) {
+			break
+		}
+	}
+	return entryList, nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"testing"
+)
+
+func TestParseShareCode(t *testing.T) {
+	assert.Equal(t, "iM7BRjvEZfUj", parseShareCode("https://cloud.189.cn/t/iM7BRjvEZfUj"))
+	assert.Equal(t, "iM7BRjvEZfUj", parseShareCode("https://cloud.189.cn/t/iM7BRjvEZfUj?from=pc"))
+	assert.Equal(t, "iM7BRjvEZfUj", parseShareCode("https://cloud.189.cn/web/share?code=iM7BRjvEZfUj"))
+	assert.Equal(t, "iM7BRjvEZfUj", parseShareCode(" iM7BRjvEZfUj "))
+}
+
+func TestShareApiError(t *testing.T) {
+	assert.Nil(t, shareApiError("", ""))
+	assert.Nil(t, shareApiError("0", ""))
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeShareExpired), shareApiError("ShareExpiredError", "").Code)
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeShareAudited), shareApiError("ShareAuditWaiting", "").Code)
+	assert.Equal(t, apierror.ApiCode(apierror.ApiCodeShareNotFound), shareApiError("ShareInfoNotFound", "").Code)
+
+	// 未知错误码不按照子串猜测
+	err := shareApiError("ShareNotExpiredButFailed", "分享异常")
+	assert.Equal(t, apierror.ApiCodeFailed, err.Code)
+	assert.Equal(t, "分享异常", err.Err)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type (
+	// ShareCreateParam 批量创建分享参数
+	ShareCreateParam struct {
+		// FamilyId 家庭云ID，个人云为0
+		FamilyId int64
+		// FileIdList 需要分享的文件ID列表，每个文件创建一个分享
+		FileIdList []string
+		// ExpiredTime 分享有效期，可以通过 ShareExpiredDays 指定任意天数
+		ExpiredTime ShareExpiredTime
+		// AccessCode 自定义访问码，为空则由服务器随机生成
+		AccessCode string
+	}
+
+	// ShareCreateResult 单个文件的分享结果
+	ShareCreateResult struct {
+		FileId        string
+		ShareId       int64
+		AccessCode    string
+		ShortShareUrl string
+		// Err 创建失败的错误信息
+		Err *apierror.ApiError
+	}
+
+	// ShareUpdateParam 修改分享参数
+	ShareUpdateParam struct {
+		// ExpiredTime 新的有效期，0代表不修改
+		ExpiredTime ShareExpiredTime
+		// AccessCode 新的访问码，为空代表不修改
+		AccessCode string
+	}
+
+	// ShareAccessStat 单个分享的访问统计
+	ShareAccessStat struct {
+		ShareItem *ShareItem
+		// Total 预览、下载、转存次数之和
+		Total int
+	}
+
+	// ShareAccessStats 所有分享的访问统计
+	ShareAccessStats struct {
+		ShareCount    int
+		PreviewCount  int
+		DownloadCount int
+		CopyCount     int
+		// Shares 按访问总次数降序排列的分享
+		Shares []*ShareAccessStat
+	}
+)
+
+const (
+	// MaxShareExpiredDays 自定义有效期的最大天数
+	MaxShareExpiredDays = 365
+)
+
+// ShareExpiredDays 指定任意天数的分享有效期，天数必须在1到 MaxShareExpiredDays 之间，永久有效请使用 ShareExpiredTimeForever
+func ShareExpiredDays(days int) (ShareExpiredTime, *apierror.ApiError) {
+	if days <= 0 || days > MaxShareExpiredDays {
+		return 0, apierror.NewFailedApiError(fmt.Sprintf("分享有效期必须在1到%d天之间", MaxShareExpiredDays))
+	}
+	return ShareExpiredTime(days), nil
+}
+
+// validAccessCode 访问码只能是4位字母或者数字
+func validAccessCode(code string) bool {
+	if len(code) != 4 {
+		return false
+	}
+	for _, c := range code {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ShareCreateBatch 为多个文件批量创建私密分享，单个文件失败不影响其他文件
+func (p *PanClient) ShareCreateBatch(param *ShareCreateParam) ([]*ShareCreateResult, *apierror.ApiError) {
+	if param.AccessCode != "" && !validAccessCode(param.AccessCode) {
+		return nil, apierror.NewFailedApiError("访问码必须是4位字母或者数字")
+	}
+	expiredTime := param.ExpiredTime
+	if expiredTime == 0 {
+		expiredTime = ShareExpiredTime7Day
+	}
+
+	results := []*ShareCreateResult{}
+	failedCount := 0
+	for _, fileId := range param.FileIdList {
+		r := &ShareCreateResult{FileId: fileId}
+		results = append(results, r)
+
+		link, err := p.createShareLink(param.FamilyId, fileId, expiredTime)
+		if err != nil {
+			r.Err = err
+			failedCount++
+			continue
+		}
+		r.ShareId = link.ShareId
+		r.AccessCode = link.AccessCode
+		r.ShortShareUrl = link.Url
+		if param.AccessCode != "" && param.AccessCode != link.AccessCode {
+			if err := p.ShareUpdate(link.ShareId, &ShareUpdateParam{AccessCode: param.AccessCode}); err != nil {
+				// 访问码不符合要求的分享不能保留，取消已经创建的分享
+				if _, cancelErr := p.ShareCancel([]int64{link.ShareId}); cancelErr != nil {
+					logger.Verboseln("cancel share failed: ", link.ShareId, cancelErr)
+					err = apierror.NewFailedApiError(err.Error() + "，并且取消已创建的分享失败: " + cancelErr.Error())
+				}
+				r.ShareId = 0
+				r.AccessCode = ""
+				r.ShortShareUrl = ""
+				r.Err = err
+				failedCount++
+				continue
+			}
+			r.AccessCode = param.AccessCode
+		}
+	}
+	if failedCount > 0 {
+		return results, apierror.NewFailedApiError(fmt.Sprintf("部分文件分享失败，失败数量：%d", failedCount))
+	}
+	return results, nil
+}
+
+// ShareUpdate 修改已有分享的有效期或者访问码
+func (p *PanClient) ShareUpdate(shareId int64, param *ShareUpdateParam) *apierror.ApiError {
+	if param.AccessCode != "" && !validAccessCode(param.AccessCode) {
+		return apierror.NewFailedApiError("访问码必须是4位字母或者数字")
+	}
+	if param.ExpiredTime <= 0 && param.AccessCode == "" {
+		return apierror.NewFailedApiError("没有需要修改的内容")
+	}
+	fullUrl := shareUpdateUrl(shareId, param)
+	logger.Verboseln("do request url: " + fullUrl)
+	headers := map[string]string{
+		"accept": "application/json;charset=UTF-8",
+	}
+	body, err := p.client.Fetch("GET", fullUrl, nil, headers)
+	if err != nil {
+		logger.Verboseln("ShareUpdate failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	type updateResp struct {
+		ResCode    String `json:"res_code"`
+		ResMessage string `json:"res_message"`
+	}
+	item := &updateResp{}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("ShareUpdate response failed")
+		return apierror.NewApiErrorWithError(err)
+	}
+	return shareApiError(item.ResCode, item.ResMessage)
+}
+
+// shareUpdateUrl 修改分享的请求地址，参数参照网页版分享管理页面的请求
+func shareUpdateUrl(shareId int64, param *ShareUpdateParam) string {
+	fullUrl := &strings.Builder{}
+	fmt.Fprintf(fullUrl, "%s/api/open/share/updateShare.action?shareId=%d", WEB_URL, shareId)
+	if param.ExpiredTime > 0 {
+		fmt.Fprintf(fullUrl, "&expireTime=%d", param.ExpiredTime)
+	}
+	if param.AccessCode != "" {
+		fmt.Fprintf(fullUrl, "&accessCode=%s", url.QueryEscape(param.AccessCode))
+	}
+	return fullUrl.String()
+}
+
+// newShareAccessStats 汇总分享的访问统计
+func newShareAccessStats(shareList ShareItemList) *ShareAccessStats {
+	stats := &ShareAccessStats{
+		Shares: []*ShareAccessStat{},
+	}
+	for _, s := range shareList {
+		if s == nil {
+			continue
+		}
+		stats.ShareCount++
+		stats.PreviewCount += s.AccessCount.PreviewCount
+		stats.DownloadCount += s.AccessCount.DownloadCount
+		stats.CopyCount += s.AccessCount.CopyCount
+		stats.Shares = append(stats.Shares, &ShareAccessStat{
+			ShareItem: s,
+			Total:     s.AccessCount.PreviewCount + s.AccessCount.DownloadCount + s.AccessCount.CopyCount,
+		})
+	}
+	sort.SliceStable(stats.Shares, func(i, j int) bool {
+		return stats.Shares[i].Total > stats.Shares[j].Total
+	})
+	return stats
+}
+
+// ShareAccessStatsReport 获取所有分享并汇总访问统计
+func (p *PanClient) ShareAccessStatsReport(ctx context.Context) (*ShareAccessStats, *apierror.ApiError) {
+	shareList := ShareItemList{}
+	c := p.ShareListCursor(ctx, NewShareListParam())
+	for c.Next() {
+		shareList = append(shareList, c.Entry())
+	}
+	if c.Err() != nil {
+		return nil, c.Err()
+	}
+	return newShareAccessStats(shareList), nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShareExpiredDays(t *testing.T) {
+	expiredTime, err := ShareExpiredDays(15)
+	assert.Nil(t, err)
+	assert.Equal(t, ShareExpiredTime(15), expiredTime)
+	_, err = ShareExpiredDays(0)
+	assert.NotNil(t, err)
+	_, err = ShareExpiredDays(MaxShareExpiredDays + 1)
+	assert.NotNil(t, err)
+}
+
+func TestShareUpdateUrl(t *testing.T) {
+	assert.Equal(t, WEB_URL+"/api/open/share/updateShare.action?shareId=12&expireTime=30&accessCode=a1B2",
+		shareUpdateUrl(12, &ShareUpdateParam{ExpiredTime: ShareExpiredTime(30), AccessCode: "a1B2"}))
+	assert.Equal(t, WEB_URL+"/api/open/share/updateShare.action?shareId=12&accessCode=a1B2",
+		shareUpdateUrl(12, &ShareUpdateParam{AccessCode: "a1B2"}))
+}
+
+func TestValidAccessCode(t *testing.T) {
+	assert.True(t, validAccessCode("a1B2"))
+	assert.False(t, validAccessCode("a1B"))
+	assert.False(t, validAccessCode("a1-2"))
+}
+
+func TestNewShareAccessStats(t *testing.T) {
+	stats := newShareAccessStats(ShareItemList{
+		{ShareId: 1, AccessCount: AccessCount{PreviewCount: 1, DownloadCount: 1}},
+		{ShareId: 2, AccessCount: AccessCount{PreviewCount: 3, CopyCount: 2}},
+		nil,
+	})
+	assert.Equal(t, 2, stats.ShareCount)
+	assert.Equal(t, 4, stats.PreviewCount)
+	assert.Equal(t, 1, stats.DownloadCount)
+	assert.Equal(t, 2, stats.CopyCount)
+	assert.Equal(t, int64(2), stats.Shares[0].ShareItem.ShareId)
+}
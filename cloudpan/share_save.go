@@ -0,0 +1,186 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"path"
+	"strings"
+)
+
+type (
+	// ShareSaveParam 转存分享参数
+	ShareSaveParam struct {
+		// AccessUrl 分享链接
+		AccessUrl string
+		// AccessCode 访问码，私密分享需要
+		AccessCode string
+		// FamilyId 转存到的家庭云ID，个人云为0
+		FamilyId int64
+		// TargetFolderId 转存到的文件夹ID
+		TargetFolderId string
+		// Paths 需要转存的分享内路径，支持通配符，为空则转存分享的全部内容。
+		// 包含"/"的规则匹配分享内的完整路径，例如"/电影/*.mp4"；不包含"/"的规则匹配文件名，例如"*.mp4"
+		Paths []string
+		// Recursive 是否进入子文件夹匹配文件名规则，否则只匹配分享根目录下的文件
+		Recursive bool
+		// RunOption 批量任务执行选项，可以指定冲突处理等，FamilyId以本参数为准
+		RunOption *BatchTaskRunOption
+	}
+
+	// ShareSaveResult 转存分享结果
+	ShareSaveResult struct {
+		// TaskId 转存任务ID
+		TaskId string
+		// Selected 选中转存的分享文件
+		Selected ShareDirEntryList
+		// SavedFileList 目标文件夹中新增的文件
+		SavedFileList AppFileList
+	}
+)
+
+// needDeepList 规则是否需要列出分享的子文件夹
+func (param *ShareSaveParam) needDeepList() bool {
+	if param.Recursive {
+		return true
+	}
+	for _, pattern := range param.Paths {
+		if strings.Count(path.Clean("/"+pattern), "/") > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// selectShareEntries 根据规则选择需要转存的文件，已选中文件夹下的文件不会重复选中
+func selectShareEntries(entryList ShareDirEntryList, patterns []string, recursive bool) ShareDirEntryList {
+	selected := ShareDirEntryList{}
+	selectedDirs := []string{}
+	for _, entry := range entryList {
+		isTopLevel := path.Dir(entry.Path) == "/"
+		inSelectedDir := false
+		for _, dir := range selectedDirs {
+			if strings.HasPrefix(entry.Path, dir+"/") {
+				inSelectedDir = true
+				break
+			}
+		}
+		if inSelectedDir {
+			continue
+		}
+
+		matched := len(patterns) == 0 && isTopLevel
+		for _, pattern := range patterns {
+			if strings.Contains(pattern, "/") {
+				if ok, _ := path.Match(path.Clean("/"+pattern), entry.Path); ok {
+					matched = true
+				}
+			} else if recursive || isTopLevel {
+				if ok, _ := path.Match(pattern, entry.FileName); ok {
+					matched = true
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		selected = append(selected, entry)
+		if entry.IsFolder {
+			selectedDirs = append(selectedDirs, entry.Path)
+		}
+	}
+	return selected
+}
+
+// ShareSaveSelective 选择性转存分享中的文件/文件夹，并等待转存完成。
+// 所有选中的文件/文件夹都会转存到目标文件夹下，不保留其在分享中的目录层级
+func (p *PanClient) ShareSaveSelective(ctx context.Context, param *ShareSaveParam) (*ShareSaveResult, *apierror.ApiError) {
+	shareInfo, err := p.GetShareInfo(param.AccessUrl, param.AccessCode)
+	if err != nil {
+		return nil, err
+	}
+	if shareInfo.NeedAccessCode == 1 && param.AccessCode == "" {
+		return nil, apierror.NewApiError(apierror.ApiCodeShareNeedAccessCode, "分享需要访问码")
+	}
+
+	entryList, err := p.ListShareDirAll(shareInfo, "", "/", param.needDeepList())
+	if err != nil {
+		return nil, err
+	}
+	selected := selectShareEntries(entryList, param.Paths, param.Recursive)
+	if len(selected) == 0 {
+		return nil, apierror.NewApiError(apierror.ApiCodeFileNotFoundCode, "分享中没有匹配的文件")
+	}
+
+	drive := p.Family(param.FamilyId)
+	targetFolderId := param.TargetFolderId
+	if targetFolderId == "" {
+		targetFolderId = NewAppFileEntityForRootDir().FileId
+	}
+	listFolderId := targetFolderId
+	// 转存前的文件列表，用于找出新转存的文件
+	beforeList, err := drive.List(listFolderId)
+	if err != nil {
+		return nil, err
+	}
+	existed := map[string]bool{}
+	for _, f := range beforeList {
+		existed[f.FileId] = true
+	}
+
+	runOpts := NewBatchTaskRunOption()
+	if param.RunOption != nil {
+		o := *param.RunOption
+		runOpts = &o
+	}
+	runOpts.FamilyId = param.FamilyId
+	if param.FamilyId > 0 && targetFolderId == NewAppFileEntityForRootDir().FileId {
+		targetFolderId = ""
+	}
+	taskResult, err := p.RunBatchTask(ctx, &BatchTaskParam{
+		TypeFlag:       BatchTaskTypeShareSave,
+		TaskInfos:      makeBatchTaskInfoListForShareSave(selected),
+		TargetFolderId: targetFolderId,
+		ShareId:        shareInfo.ShareId,
+	}, runOpts)
+	result := &ShareSaveResult{
+		Selected:      selected,
+		SavedFileList: AppFileList{},
+	}
+	if taskResult != nil {
+		result.TaskId = taskResult.TaskId
+	}
+
+	// 任务失败时可能已经有部分文件转存成功，同样需要找出新转存的文件
+	afterList, listErr := drive.List(listFolderId)
+	if listErr != nil {
+		if err != nil {
+			logger.Verboseln("list saved files failed: ", listErr.Error())
+			return result, err
+		}
+		return result, listErr
+	}
+	for _, f := range afterList {
+		if !existed[f.FileId] {
+			result.SavedFileList = append(result.SavedFileList, f)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelectShareEntries(t *testing.T) {
+	entryList := ShareDirEntryList{
+		{FileName: "movie", Path: "/movie", IsFolder: true},
+		{FileName: "a.mp4", Path: "/movie/a.mp4"},
+		{FileName: "b.txt", Path: "/movie/b.txt"},
+		{FileName: "c.mp4", Path: "/c.mp4"},
+	}
+
+	selected := selectShareEntries(entryList, nil, false)
+	assert.Equal(t, 2, len(selected))
+
+	selected = selectShareEntries(entryList, []string{"*.mp4"}, false)
+	assert.Equal(t, 1, len(selected))
+	assert.Equal(t, "/c.mp4", selected[0].Path)
+
+	selected = selectShareEntries(entryList, []string{"*.mp4"}, true)
+	assert.Equal(t, 2, len(selected))
+
+	selected = selectShareEntries(entryList, []string{"/movie/*"}, false)
+	assert.Equal(t, 2, len(selected))
+
+	// 文件夹已选中，其下的文件不再重复选中
+	selected = selectShareEntries(entryList, []string{"movie", "*.txt"}, true)
+	assert.Equal(t, 1, len(selected))
+	assert.Equal(t, "/movie", selected[0].Path)
+
+	assert.True(t, (&ShareSaveParam{Paths: []string{"/movie/*.mp4"}}).needDeepList())
+	assert.False(t, (&ShareSaveParam{Paths: []string{"*.mp4"}}).needDeepList())
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"context"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+	"time"
+)
+
+type (
+	// ShareProblem 分享存在的问题
+	ShareProblem int
+
+	// ShareScanOption 分享扫描选项
+	ShareScanOption struct {
+		// CheckFileExists 是否检查分享的文件是否已被删除，依次在个人云和家庭云中查找文件，每个分享需要额外请求
+		CheckFileExists bool
+		// CancelProblems 是否取消存在问题的分享
+		CancelProblems bool
+		// RecreateExpired 是否为已过期的分享重新创建分享，使用原有的分享模式和有效期天数
+		RecreateExpired bool
+	}
+
+	// ShareScanResult 存在问题的分享
+	ShareScanResult struct {
+		ShareItem *ShareItem
+		Problem   ShareProblem
+		// Cancelled 是否已取消该分享
+		Cancelled bool
+		// FamilyId 分享的文件所在的家庭云ID，个人云为0，只有检查过文件来源才有意义
+		FamilyId int64
+		// Recreated 重新创建的私密分享
+		Recreated *PrivateShareResult
+		// RecreatedPublic 重新创建的公开分享
+		RecreatedPublic *PublicShareResult
+		// Err 取消或者重新创建分享时的错误
+		Err *apierror.ApiError
+	}
+
+	// shareFileResolver 查找分享文件所在的个人云或者家庭云，家庭云列表只获取一次
+	shareFileResolver struct {
+		p         *PanClient
+		familyIds []int64
+		loaded    bool
+	}
+)
+
+const (
+	// ShareProblemBlocked 审核未通过被屏蔽
+	ShareProblemBlocked ShareProblem = 1
+	// ShareProblemExpired 已过期
+	ShareProblemExpired ShareProblem = 2
+	// ShareProblemFileDeleted 分享的文件已被删除
+	ShareProblemFileDeleted ShareProblem = 3
+)
+
+func (s ShareReviewStatus) String() string {
+	switch s {
+	case ShareReviewStatusAuditing:
+		return "审核中"
+	case ShareReviewStatusNormal:
+		return "正常"
+	case ShareReviewStatusBlocked:
+		return "审核未通过"
+	default:
+		return "未知"
+	}
+}
+
+func (sp ShareProblem) String() string {
+	switch sp {
+	case ShareProblemBlocked:
+		return "审核未通过"
+	case ShareProblemExpired:
+		return "已过期"
+	case ShareProblemFileDeleted:
+		return "文件已删除"
+	default:
+		return "未知"
+	}
+}
+
+// IsForever 分享是否永久有效
+func (s *ShareItem) IsForever() bool {
+	return s.ExpireTime <= 0 || s.ExpireTime >= ShareExpiredTimeForever
+}
+
+// ExpiredAt 分享的过期时间，永久有效的分享返回零值
+func (s *ShareItem) ExpiredAt() time.Time {
+	if s.IsForever() || s.ShareDate <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, s.ShareDate*int64(time.Millisecond)).AddDate(0, 0, int(s.ExpireTime))
+}
+
+// IsExpired 分享在指定时间是否已过期
+func (s *ShareItem) IsExpired(now time.Time) bool {
+	expiredAt := s.ExpiredAt()
+	return !expiredAt.IsZero() && !now.Before(expiredAt)
+}
+
+// shareProblemOf 检查分享的审核状态和有效期
+func shareProblemOf(s *ShareItem, now time.Time) ShareProblem {
+	if s.ReviewStatus == ShareReviewStatusBlocked {
+		return ShareProblemBlocked
+	}
+	if s.IsExpired(now) {
+		return ShareProblemExpired
+	}
+	return 0
+}
+
+// ShareScan 扫描所有分享，返回被屏蔽、已过期或者文件已被删除的分享，并按选项取消或者重新创建
+func (p *PanClient) ShareScan(ctx context.Context, opts *ShareScanOption) ([]*ShareScanResult, *apierror.ApiError) {
+	if opts == nil {
+		opts = &ShareScanOption{}
+	}
+	now := time.Now()
+	results := []*ShareScanResult{}
+	resolver := &shareFileResolver{p: p}
+	c := p.ShareListCursor(ctx, NewShareListParam())
+	for c.Next() {
+		s := c.Entry()
+		problem := shareProblemOf(s, now)
+		familyId := int64(0)
+		if problem == 0 && opts.CheckFileExists {
+			fid, found, err := resolver.resolve(s.FileId)
+			if err != nil {
+				logger.Verboseln("check share file failed: ", s.FileId, " ", err.Error())
+			} else if !found {
+				problem = ShareProblemFileDeleted
+			}
+			familyId = fid
+		}
+		if problem != 0 {
+			results = append(results, &ShareScanResult{
+				ShareItem: s,
+				Problem:   problem,
+				FamilyId:  familyId,
+			})
+		}
+	}
+	if c.Err() != nil {
+		return results, c.Err()
+	}
+
+	for _, r := range results {
+		if opts.RecreateExpired && r.Problem == ShareProblemExpired {
+			familyId, found, err := resolver.resolve(r.ShareItem.FileId)
+			if err == nil && !found {
+				err = apierror.NewFailedApiError("分享的文件已被删除，无法重新创建分享")
+			}
+			if err != nil {
+				r.Err = err
+				continue
+			}
+			r.FamilyId = familyId
+			if err := p.recreateShare(r); err != nil {
+				r.Err = err
+				continue
+			}
+		}
+		if opts.CancelProblems {
+			if _, err := p.ShareCancel([]int64{r.ShareItem.ShareId}); err != nil {
+				r.Err = err
+				continue
+			}
+			r.Cancelled = true
+		}
+	}
+	return results, nil
+}
+
+// recreateShare 按原有的分享模式和文件来源重新创建分享
+func (p *PanClient) recreateShare(r *ShareScanResult) *apierror.ApiError {
+	s := r.ShareItem
+	if s.ShareMode == ShareModePublic {
+		if r.FamilyId > 0 {
+			return apierror.NewFailedApiError("家庭云文件不支持重新创建公开分享")
+		}
+		recreated, err := p.SharePublic(s.FileId, s.ExpireTime)
+		if err != nil {
+			return err
+		}
+		r.RecreatedPublic = recreated
+		return nil
+	}
+	recreated, err := p.sharePrivate(r.FamilyId, s.FileId, s.ExpireTime)
+	if err != nil {
+		return err
+	}
+	r.Recreated = recreated
+	return nil
+}
+
+// resolve 依次在个人云和各个家庭云中查找文件，返回文件所在的家庭云ID（个人云为0）以及文件是否存在
+func (r *shareFileResolver) resolve(fileId string) (int64, bool, *apierror.ApiError) {
+	_, err := r.p.AppGetBasicFileInfo(&AppGetFileInfoParam{FileId: fileId})
+	if err == nil {
+		return 0, true, nil
+	}
+	if err.Code != apierror.ApiCodeFileNotFoundCode {
+		return 0, false, err
+	}
+	if !r.loaded {
+		familyList, err := r.p.AppFamilyGetFamilyList()
+		if err != nil {
+			return 0, false, err
+		}
+		for _, f := range familyList.FamilyInfoList {
+			if f != nil {
+				r.familyIds = append(r.familyIds, f.FamilyId)
+			}
+		}
+		r.loaded = true
+	}
+	for _, familyId := range r.familyIds {
+		_, err := r.p.AppGetBasicFileInfo(&AppGetFileInfoParam{FamilyId: familyId, FileId: fileId})
+		if err == nil {
+			return familyId, true, nil
+		}
+		if err.Code != apierror.ApiCodeFileNotFoundCode {
+			return 0, false, err
+		}
+	}
+	return 0, false, nil
+}
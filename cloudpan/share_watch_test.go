@@ -0,0 +1,40 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestShareItemExpiredAt(t *testing.T) {
+	shareDate := time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local)
+	s := &ShareItem{
+		ShareDate:  shareDate.UnixNano() / int64(time.Millisecond),
+		ExpireTime: ShareExpiredTime7Day,
+	}
+	assert.Equal(t, shareDate.AddDate(0, 0, 7), s.ExpiredAt())
+	assert.False(t, s.IsExpired(shareDate.AddDate(0, 0, 6)))
+	assert.True(t, s.IsExpired(shareDate.AddDate(0, 0, 7)))
+	assert.Equal(t, ShareProblemExpired, shareProblemOf(s, shareDate.AddDate(0, 0, 8)))
+
+	s.ExpireTime = ShareExpiredTimeForever
+	assert.True(t, s.ExpiredAt().IsZero())
+	assert.False(t, s.IsExpired(shareDate.AddDate(100, 0, 0)))
+
+	s.ReviewStatus = ShareReviewStatusBlocked
+	assert.Equal(t, ShareProblemBlocked, shareProblemOf(s, shareDate))
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2020 tickstep.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudpan
+
+import (
+	"encoding/json"
+	"github.com/tickstep/cloudpan189-api/cloudpan/apierror"
+	"github.com/tickstep/library-go/logger"
+)
+
+type (
+	// CapacitySize 空间容量
+	CapacitySize struct {
+		// Total 总空间大小
+		Total int64 `json:"total"`
+		// Used 已使用空间大小
+		Used int64 `json:"used"`
+		// Available 剩余可用空间大小
+		Available int64 `json:"available"`
+	}
+
+	// CapacityCategory 分类空间占用
+	CapacityCategory struct {
+		// MediaType 媒体类型
+		MediaType MediaType `json:"mediaType"`
+		// Name 分类名称
+		Name string `json:"name"`
+		// UsedSize 已使用空间大小
+		UsedSize int64 `json:"usedSize"`
+		// FileCount 文件数量
+		FileCount int64 `json:"fileCount"`
+	}
+
+	// UploadFlowInfo 每日上传流量信息
+	UploadFlowInfo struct {
+		// DayFlowLimit 每日可上传流量，0代表不限制
+		DayFlowLimit int64 `json:"dayFlowLimit"`
+		// DayFlowUsed 今日已上传流量
+		DayFlowUsed int64 `json:"dayFlowUsed"`
+	}
+
+	// UserCapacityInfo 用户空间容量详情
+	UserCapacityInfo struct {
+		// Personal 个人云容量
+		Personal CapacitySize `json:"personal"`
+		// Family 家庭云容量
+		Family CapacitySize `json:"family"`
+		// Categories 个人云分类占用
+		Categories []*CapacityCategory `json:"categories"`
+		// MaxFileSize 单文件最大上传大小，0代表未知
+		MaxFileSize int64 `json:"maxFileSize"`
+		// UploadFlow 每日上传流量
+		UploadFlow UploadFlowInfo `json:"uploadFlow"`
+		// Vip 会员等级
+		Vip UserVip `json:"vip"`
+		// VipBeginTime 会员开始时间
+		VipBeginTime string `json:"vipBeginTime"`
+		// VipExpireTime 会员到期时间
+		VipExpireTime string `json:"vipExpireTime"`
+	}
+
+	userSizeInfoResp struct {
+		ResCode           int    `json:"res_code"`
+		ResMessage        string `json:"res_message"`
+		Account           string `json:"account"`
+		CloudCapacityInfo struct {
+			FreeSize        int64 `json:"freeSize"`
+			Mail189UsedSize int64 `json:"mail189UsedSize"`
+			TotalSize       int64 `json:"totalSize"`
+			UsedSize        int64 `json:"usedSize"`
+		} `json:"cloudCapacityInfo"`
+		FamilyCapacityInfo struct {
+			FreeSize  int64 `json:"freeSize"`
+			TotalSize int64 `json:"totalSize"`
+			UsedSize  int64 `json:"usedSize"`
+		} `json:"familyCapacityInfo"`
+		CategoryList []struct {
+			MediaType MediaType `json:"mediaType"`
+			Name      string    `json:"name"`
+			UsedSize  int64     `json:"usedSize"`
+			FileCount int64     `json:"fileCount"`
+		} `json:"categoryList"`
+	}
+
+	userVipInfoResp struct {
+		ResCode    int    `json:"res_code"`
+		ResMessage string `json:"res_message"`
+		// 会员开始时间
+		SuperBeginTime string `json:"superBeginTime"`
+		// 会员结束时间
+		SuperEndTime string `json:"superEndTime"`
+		// VIP会员标志位
+		SuperVip int `json:"superVip"`
+	}
+
+	userFlowInfoResp struct {
+		ResCode      int    `json:"res_code"`
+		ResMessage   string `json:"res_message"`
+		DayFlowLimit int64  `json:"dayFlowLimit"`
+		DayFlowUsed  int64  `json:"dayFlowUsed"`
+	}
+)
+
+// Remain 今日剩余可上传流量，不限制则返回-1
+func (u UploadFlowInfo) Remain() int64 {
+	if u.DayFlowLimit <= 0 {
+		return -1
+	}
+	if u.DayFlowUsed >= u.DayFlowLimit {
+		return 0
+	}
+	return u.DayFlowLimit - u.DayFlowUsed
+}
+
+// ParseUserVip 将接口返回的会员标志位转换为 UserVip
+func ParseUserVip(superVip int) UserVip {
+	switch UserVip(superVip) {
+	case VipFamilyGold, VipGold, VipFamilyPlatnum, VipPlatnum:
+		return UserVip(superVip)
+	default:
+		return VipUser
+	}
+}
+
+func (v UserVip) String() string {
+	switch v {
+	case VipFamilyGold:
+		return "家庭黄金会员"
+	case VipGold:
+		return "黄金会员"
+	case VipFamilyPlatnum:
+		return "家庭铂金会员"
+	case VipPlatnum:
+		return "铂金会员"
+	default:
+		return "普通会员"
+	}
+}
+
+// GetCapacity 获取个人云和家庭云的空间容量、分类占用、每日上传流量以及会员信息
+func (p *PanClient) GetCapacity() (*UserCapacityInfo, *apierror.ApiError) {
+	sizeInfo := &userSizeInfoResp{}
+	if err := p.getPortalJson("/api/portal/getUserSizeInfo.action", sizeInfo); err != nil {
+		return nil, err
+	}
+	if sizeInfo.ResCode != 0 {
+		return nil, apierror.NewFailedApiError(sizeInfo.ResMessage)
+	}
+
+	result := &UserCapacityInfo{
+		Personal: CapacitySize{
+			Total:     sizeInfo.CloudCapacityInfo.TotalSize,
+			Used:      sizeInfo.CloudCapacityInfo.UsedSize,
+			Available: sizeInfo.CloudCapacityInfo.FreeSize,
+		},
+		Family: CapacitySize{
+			Total:     sizeInfo.FamilyCapacityInfo.TotalSize,
+			Used:      sizeInfo.FamilyCapacityInfo.UsedSize,
+			Available: sizeInfo.FamilyCapacityInfo.FreeSize,
+		},
+		Categories: []*CapacityCategory{},
+	}
+	for _, c := range sizeInfo.CategoryList {
+		result.Categories = append(result.Categories, &CapacityCategory{
+			MediaType: c.MediaType,
+			Name:      c.Name,
+			UsedSize:  c.UsedSize,
+			FileCount: c.FileCount,
+		})
+	}
+
+	// 以下信息获取失败不影响容量结果
+	if ui, err := p.getUserInfoForPortal(); err == nil {
+		result.MaxFileSize = ui.MaxFilesize
+	} else {
+		logger.Verboseln("get max file size failed: ", err)
+	}
+
+	vipInfo := &userVipInfoResp{}
+	if err := p.getPortalJson("/api/portal/getUserVipInfo.action", vipInfo); err == nil {
+		result.Vip = ParseUserVip(vipInfo.SuperVip)
+		result.VipBeginTime = vipInfo.SuperBeginTime
+		result.VipExpireTime = vipInfo.SuperEndTime
+	} else {
+		logger.Verboseln("get vip info failed: ", err)
+	}
+
+	flowInfo := &userFlowInfoResp{}
+	if err := p.getPortalJson("/api/portal/getUserFlowInfo.action", flowInfo); err == nil {
+		result.UploadFlow.DayFlowLimit = flowInfo.DayFlowLimit
+		result.UploadFlow.DayFlowUsed = flowInfo.DayFlowUsed
+	} else {
+		logger.Verboseln("get upload flow info failed: ", err)
+	}
+	return result, nil
+}
+
+// getPortalJson 请求WEB端JSON接口并解析到item
+func (p *PanClient) getPortalJson(apiPath string, item interface{}) *apierror.ApiError {
+	header := map[string]string{
+		"accept": "application/json;charset=UTF-8",
+	}
+	fullUrl := WEB_URL + apiPath
+	logger.Verboseln("do request url: " + fullUrl)
+	body, err := p.client.Fetch("GET", fullUrl, nil, header)
+	if err != nil {
+		logger.Verboseln("request failed: " + apiPath)
+		return apierror.NewApiErrorWithError(err)
+	}
+	logger.Verboseln("response: " + string(body))
+
+	es := &apierror.ErrorResp{}
+	if err := json.Unmarshal(body, es); err == nil {
+		if es.ErrorCode == "InvalidSessionKey" {
+			return apierror.NewApiError(apierror.ApiCodeTokenExpiredCode, "登录超时")
+		} else if es.ErrorCode != "" {
+			return apierror.NewFailedApiError(es.ErrorMsg)
+		}
+	}
+	if err := json.Unmarshal(body, item); err != nil {
+		logger.Verboseln("parse response failed: " + apiPath)
+		return apierror.NewApiErrorWithError(err)
+	}
+	return nil
+}
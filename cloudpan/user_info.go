@@ -50,6 +50,29 @@ type (
 		//SuperVip UserVip `json:"superVip"`
 	}
 
+	userInfoForPortal struct {
+		ResCode         int    `json:"res_code"`
+		ResMessage      string `json:"res_message"`
+		Available       int64  `json:"available"`
+		Capacity        int64  `json:"capacity"`
+		DomainName      string `json:"domainName"`
+		ExtPicAvailable int    `json:"extPicAvailable"`
+		ExtPicCapacity  int    `json:"extPicCapacity"`
+		ExtPicUsed      int    `json:"extPicUsed"`
+		HasFamily       int    `json:"hasFamily"`
+		LoginName       string `json:"loginName"`
+		Mail189UsedSize int    `json:"mail189UsedSize"`
+		MaxFilesize     int64  `json:"maxFilesize"`
+		OrderAmount     int    `json:"orderAmount"`
+		ProvinceCode    string `json:"provinceCode"`
+		UserExtResp     struct {
+			DomainSpaceAccount string `json:"domainSpaceAccount"`
+			Gender             string `json:"gender"`
+			NickName           string `json:"nickName"`
+			SafeQustion        int    `json:"safeQustion"`
+		} `json:"userExtResp"`
+	}
+
 	UserDetailInfo struct {
 		// 性别 F-女 M-男
 		Gender string `json:"gender"`
@@ -87,7 +110,8 @@ const (
 	VipUser UserVip = 0
 )
 
-func (p *PanClient) GetUserInfo() (userInfo *UserInfo, error *apierror.ApiError) {
+// getUserInfoForPortal 获取WEB端的用户信息
+func (p *PanClient) getUserInfoForPortal() (*userInfoForPortal, *apierror.ApiError) {
 	header := map[string]string{
 		"accept": "application/json;charset=UTF-8",
 	}
@@ -110,34 +134,19 @@ func (p *PanClient) GetUserInfo() (userInfo *UserInfo, error *apierror.ApiError)
 		return nil, apierror.NewApiError(apierror.ApiCodeTokenExpiredCode, "登录超时")
 	}
 
-	type userInfoForPortal struct {
-		ResCode         int    `json:"res_code"`
-		ResMessage      string `json:"res_message"`
-		Available       int64  `json:"available"`
-		Capacity        int64  `json:"capacity"`
-		DomainName      string `json:"domainName"`
-		ExtPicAvailable int    `json:"extPicAvailable"`
-		ExtPicCapacity  int    `json:"extPicCapacity"`
-		ExtPicUsed      int    `json:"extPicUsed"`
-		HasFamily       int    `json:"hasFamily"`
-		LoginName       string `json:"loginName"`
-		Mail189UsedSize int    `json:"mail189UsedSize"`
-		MaxFilesize     int64  `json:"maxFilesize"`
-		OrderAmount     int    `json:"orderAmount"`
-		ProvinceCode    string `json:"provinceCode"`
-		UserExtResp     struct {
-			DomainSpaceAccount string `json:"domainSpaceAccount"`
-			Gender             string `json:"gender"`
-			NickName           string `json:"nickName"`
-			SafeQustion        int    `json:"safeQustion"`
-		} `json:"userExtResp"`
-	}
-
 	ui := &userInfoForPortal{}
 	if err := json.Unmarshal(body, ui); err != nil {
 		logger.Verboseln("get user info failed")
 		return nil, apierror.NewApiErrorWithError(err)
 	}
+	return ui, nil
+}
+
+func (p *PanClient) GetUserInfo() (userInfo *UserInfo, error *apierror.ApiError) {
+	ui, err := p.getUserInfoForPortal()
+	if err != nil {
+		return nil, err
+	}
 	userId, _ := strconv.ParseInt(ui.DomainName, 10, 0)
 	userInfo = &UserInfo{
 		UserId:      uint64(userId),